@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokeapi"
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+const cacheUsage = "usage: cache stats | list | show <key> | evict <key> | clear | export <file> | import <file> [newest | overwrite | skip]"
+
+func commandCache(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(cacheUsage)
+	}
+	cache := config.Cache
+	switch args[0] {
+	case "stats":
+		stats := cache.Stats()
+		fmt.Printf("Entries: %v \n", stats.Entries)
+		fmt.Printf("Bytes: %v \n", stats.Bytes)
+		if stats.RawBytes != stats.Bytes {
+			fmt.Printf("Uncompressed bytes: %v (ratio %.1fx) \n", stats.RawBytes, stats.CompressionRatio())
+		}
+		fmt.Printf("Hits: %v \n", stats.Hits)
+		fmt.Printf("Misses: %v \n", stats.Misses)
+		fmt.Printf("Evictions: %v \n", stats.Evictions)
+		fmt.Printf("Expirations: %v \n", stats.Expirations)
+		return nil
+	case "list":
+		now := time.Now()
+		for _, entry := range cache.Entries() {
+			fmt.Printf(" . -%s (%v bytes, %s) \n", entry.Key, len(entry.Value), expiresIn(entry.ExpiresAt, now))
+		}
+		return nil
+	case "show":
+		if len(args) < 2 {
+			return errors.New("no key given")
+		}
+		// keys are canonical, "show .../pokemon/pikachu" finds ".../pokemon/pikachu/"
+		entry, ok := cache.Peek(pokeapi.CacheKey(args[1]))
+		if !ok {
+			return fmt.Errorf("%s is not cached", args[1])
+		}
+		fmt.Printf("Key: %s \n", entry.Key)
+		fmt.Printf("Created: %s \n", entry.CreatedAt.Format(time.DateTime))
+		fmt.Printf("Expires: %s (%s) \n", entry.ExpiresAt.Format(time.DateTime), expiresIn(entry.ExpiresAt, time.Now()))
+		fmt.Printf("Bytes: %v \n", len(entry.Value))
+		fmt.Println(string(entry.Value))
+		return nil
+	case "evict":
+		if len(args) < 2 {
+			return errors.New("no key given")
+		}
+		if !cache.Delete(pokeapi.CacheKey(args[1])) {
+			return fmt.Errorf("%s is not cached", args[1])
+		}
+		fmt.Printf("evicted %s \n", args[1])
+		return nil
+	case "clear":
+		cache.Clear()
+		fmt.Println("cache cleared")
+		return nil
+	case "export":
+		if len(args) < 2 {
+			return errors.New("no file given")
+		}
+		return exportCache(cache, args[1])
+	case "import":
+		if len(args) < 2 {
+			return errors.New("no file given")
+		}
+		policy := pokecache.KeepNewest
+		if len(args) > 2 {
+			var err error
+			if policy, err = pokecache.ParseConflictPolicy(args[2]); err != nil {
+				return err
+			}
+		}
+		return importCache(cache, args[1], policy)
+	}
+	return fmt.Errorf("unknown cache command %q, %s", args[0], cacheUsage)
+}
+
+// exportCache writes a snapshot of the cache to path
+func exportCache(cache *pokecache.Cache, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	n, err := cache.Export(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("exported %d entries to %s \n", n, path)
+	return nil
+}
+
+// importCache adds the entries of the snapshot at path to the cache
+func importCache(cache *pokecache.Cache, path string, policy pokecache.ConflictPolicy) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	result, err := cache.Import(file, policy)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d entries from %s, skipped %d, %d expired \n", result.Imported, path, result.Skipped, result.Expired)
+	return nil
+}
+
+// expiresIn describes when an entry expires, relative to now
+func expiresIn(expiresAt, now time.Time) string {
+	if !now.Before(expiresAt) {
+		return "expired"
+	}
+	return "expires in " + expiresAt.Sub(now).Round(time.Second).String()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+const debugUsage = "usage: debug [on | off]"
+
+func commandDebug(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		if config.LogLevel.Level() <= slog.LevelDebug {
+			fmt.Println("debug mode is on")
+		} else {
+			fmt.Println("debug mode is off")
+		}
+		return nil
+	}
+	switch args[0] {
+	case "on":
+		config.LogLevel.Set(slog.LevelDebug)
+		fmt.Println("debug mode on, tracing the requests of every command")
+		return nil
+	case "off":
+		config.LogLevel.Set(slog.LevelWarn)
+		fmt.Println("debug mode off")
+		return nil
+	}
+	return errors.New(debugUsage)
+}
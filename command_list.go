@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const listUsage = "usage: list <resource> [back | all], for example list pokemon"
+
+// listing is where the list command is in the list of a resource
+type listing struct {
+	next     string // cursor of the next page, empty after the last page
+	previous string // cursor of the page before the last one shown
+}
+
+func commandList(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(listUsage)
+	}
+	resource := strings.Trim(strings.ToLower(args[0]), "/")
+	if len(args) == 1 {
+		return listPage(ctx, config, resource, false)
+	}
+	switch args[1] {
+	case "back":
+		return listPage(ctx, config, resource, true)
+	case "all":
+		return listAll(ctx, config, resource)
+	}
+	return errors.New(listUsage)
+}
+
+// listPage prints the names on the next page of resource, or the previous
+// page when back is true, and remembers where it is
+func listPage(ctx context.Context, config *Config, resource string, back bool) error {
+	position, started := config.Listings[resource]
+	pageURL := ""
+	switch {
+	case back:
+		if position.previous == "" {
+			return errors.New("no previous page")
+		}
+		pageURL = position.previous
+	case started:
+		if position.next == "" {
+			return errors.New("no next page")
+		}
+		pageURL = position.next
+	}
+
+	page, err := config.Client.ListResources(ctx, resource, pageURL, config.PageSize)
+	if err != nil {
+		return err
+	}
+	for _, result := range page.Results {
+		fmt.Println(result.Name)
+	}
+	config.Listings[resource] = listing{next: page.Next, previous: page.Previous}
+	return nil
+}
+
+// listAll prints every name in the list of resource, page by page
+func listAll(ctx context.Context, config *Config, resource string) error {
+	count := 0
+	for result, err := range config.Client.Resources(ctx, resource, config.PageSize) {
+		if err != nil {
+			return err
+		}
+		fmt.Println(result.Name)
+		count++
+	}
+	fmt.Printf("%d %s \n", count, resource)
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const offlineUsage = "usage: offline [on | off]"
+
+func commandOffline(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		if config.Client.Offline() {
+			fmt.Println("offline mode is on")
+		} else {
+			fmt.Println("offline mode is off")
+		}
+		return nil
+	}
+	switch args[0] {
+	case "on":
+		config.Client.SetOffline(true)
+		fmt.Println("offline mode on, answering from the cache only")
+		return nil
+	case "off":
+		config.Client.SetOffline(false)
+		fmt.Println("offline mode off")
+		return nil
+	}
+	return errors.New(offlineUsage)
+}
@@ -0,0 +1,85 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// Backend is where a Client gets the PokeAPI resources it doesn't have
+// cached. URLs are absolute and canonical, see canonicalURL.
+type Backend interface {
+	// Fetch gets the resource at url. With validators, the backend may
+	// answer that the cached resource is still current instead.
+	// Failures are returned as a *RequestError.
+	Fetch(ctx context.Context, url string, validators pokecache.Validators) (Response, error)
+}
+
+// Response is a resource fetched by a Backend. The caller must close Body.
+type Response struct {
+	Body        io.ReadCloser        // decoded as it streams in, up to the size limit of the client
+	NotModified bool                 // the cached resource is current, Body is empty
+	Validators  pokecache.Validators // to revalidate the resource later, if supported
+}
+
+// WithBackend replaces the HTTP backend of the client, for example with a
+// DumpBackend. Retries, timeouts and the rate limit only apply to the HTTP
+// backend.
+func WithBackend(backend Backend) Option {
+	return func(c *Client) {
+		c.backend = backend
+	}
+}
+
+// httpBackend fetches resources from the PokeAPI over HTTP, with the
+// retries, timeout and rate limit of its client
+type httpBackend struct {
+	client *Client
+}
+
+// Fetch sends a GET request for url. With validators, the request is
+// conditional and the response is 304 Not Modified when the cached body is
+// still current.
+func (b httpBackend) Fetch(ctx context.Context, url string, validators pokecache.Validators) (Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Response{}, err
+	}
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+	res, err := b.client.do(req)
+	if err != nil {
+		return Response{}, err
+	}
+	return Response{
+		Body:        requestBody{res.Body, url},
+		NotModified: res.StatusCode == http.StatusNotModified,
+		Validators: pokecache.Validators{
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
+// requestBody is the body of a response to a request for url. Failing to
+// read it is a failure of the request, returned as a *RequestError, so it
+// is told apart from a body that can't be decoded.
+type requestBody struct {
+	io.ReadCloser
+	url string
+}
+
+func (b requestBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = &RequestError{URL: b.url, Attempts: 1, Err: err}
+	}
+	return n, err
+}
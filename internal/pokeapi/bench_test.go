@@ -0,0 +1,135 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// largePokemonJSON builds a body shaped like a real /pokemon response,
+// with many moves, game indices and sprite URLs
+func largePokemonJSON(b *testing.B) []byte {
+	b.Helper()
+	namedURL := func(kind string, i int) map[string]any {
+		return map[string]any{
+			"name": fmt.Sprintf("%s-%d", kind, i),
+			"url":  fmt.Sprintf("https://pokeapi.co/api/v2/%s/%d/", kind, i),
+		}
+	}
+	sprite := func(path string) string {
+		return "https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/" + path + "/25.png"
+	}
+
+	moves := []any{}
+	for i := 0; i < 100; i++ {
+		details := []any{}
+		for j := 0; j < 15; j++ {
+			details = append(details, map[string]any{
+				"level_learned_at":  j,
+				"move_learn_method": namedURL("move-learn-method", j),
+				"version_group":     namedURL("version-group", j),
+			})
+		}
+		moves = append(moves, map[string]any{
+			"move":                  namedURL("move", i),
+			"version_group_details": details,
+		})
+	}
+	gameIndices := []any{}
+	for i := 0; i < 20; i++ {
+		gameIndices = append(gameIndices, map[string]any{
+			"game_index": i,
+			"version":    namedURL("version", i),
+		})
+	}
+	versions := map[string]any{}
+	for _, generation := range []string{"generation-i", "generation-ii", "generation-iii", "generation-iv", "generation-v"} {
+		versions[generation] = map[string]any{
+			"red-blue": map[string]any{
+				"back_default":  sprite(generation + "/back"),
+				"front_default": sprite(generation + "/front"),
+				"front_gray":    sprite(generation + "/gray"),
+			},
+		}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"id":              25,
+		"name":            "pikachu",
+		"base_experience": 112,
+		"height":          4,
+		"weight":          60,
+		"game_indices":    gameIndices,
+		"moves":           moves,
+		"stats": []any{
+			map[string]any{"base_stat": 35, "effort": 0, "stat": namedURL("stat", 1)},
+			map[string]any{"base_stat": 55, "effort": 0, "stat": namedURL("stat", 2)},
+		},
+		"types": []any{
+			map[string]any{"slot": 1, "type": namedURL("type", 13)},
+		},
+		"sprites": map[string]any{
+			"front_default": sprite("front"),
+			"back_default":  sprite("back"),
+			"versions":      versions,
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+// BenchmarkGetPokemonCached catches the same species over and over, so
+// every call after the first one is a cache hit
+func BenchmarkGetPokemonCached(b *testing.B) {
+	body := largePokemonJSON(b)
+	b.Logf("pokemon body: %d bytes", len(body))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	// raw is how hits were served before decoded values were cached:
+	// unmarshal the cached bytes again on every hit
+	b.Run("raw", func(b *testing.B) {
+		cache := pokecache.NewCache(time.Hour)
+		defer cache.Close()
+		cache.Add(server.URL+"/pokemon/pikachu", body)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			val, ok := cache.Get(server.URL + "/pokemon/pikachu")
+			if !ok {
+				b.Fatal("expected a cache hit")
+			}
+			pokemon := Pokemon{}
+			if err := json.Unmarshal(val, &pokemon); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("decoded", func(b *testing.B) {
+		cache := pokecache.NewCache(time.Hour)
+		defer cache.Close()
+		client := NewClient(cache, server.URL)
+		ctx := context.Background()
+		if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,433 @@
+package pokeapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// DefaultBaseURL is the public PokeAPI endpoint
+const DefaultBaseURL = "https://pokeapi.co/api/v2"
+
+// DefaultTimeout is the timeout of a single attempt of a request
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxResponseBytes is the largest response body a Client accepts,
+// well above the size of the largest PokeAPI resources
+const DefaultMaxResponseBytes = 8 << 20
+
+// maxErrorBody is how much of the body of a failed response is kept
+const maxErrorBody = 64 << 10
+
+// apiPrefix is the path every PokeAPI resource lives under, on the public
+// API as well as on mirrors
+const apiPrefix = "/api/v2/"
+
+// Client talks to the PokeAPI, through its Backend, and caches every
+// response body
+type Client struct {
+	cache            *pokecache.Cache
+	baseURL          string
+	httpClient       http.Client
+	retry            RetryPolicy
+	timeout          time.Duration                              // limit of a single attempt, 0 means none
+	sleep            func(context.Context, time.Duration) error // waits between retries, replaced in tests
+	limiter          *limiter                                   // nil means no rate limit
+	maxResponseBytes int64                                      // limit of a response body, 0 means none
+	backend          Backend                                    // where responses come from
+	logger           *slog.Logger                               // traces requests and cache decisions
+
+	offline              atomic.Bool // answer from the cache only
+	staleWhileRevalidate bool
+	flights              flightGroup     // requests in flight, shared by concurrent callers
+	refreshes            sync.WaitGroup  // background refreshes in flight
+	refreshing           map[string]bool // urls refreshed in the background
+	refreshMutex         sync.Mutex      // guards refreshing
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithRetryPolicy sets how failed requests are retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithTimeout limits how long a single attempt of a request may take,
+// including reading the body. 0 disables the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// constructor for Client
+// cache: cache shared by every request made through the client
+// baseURL: root of the API, an empty string uses DefaultBaseURL
+func NewClient(cache *pokecache.Cache, baseURL string, opts ...Option) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c := &Client{
+		cache:   cache,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		retry:   DefaultRetryPolicy,
+		timeout: DefaultTimeout,
+
+		maxResponseBytes: DefaultMaxResponseBytes,
+		sleep:            sleep,
+		logger:           slog.New(discardHandler{}),
+
+		refreshing: make(map[string]bool),
+	}
+	c.backend = httpBackend{c}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithMaxResponseBytes limits the size of the response bodies, larger ones
+// fail. 0 removes the limit.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithLogger logs what the client does to logger. Every request, cache hit
+// or miss, HTTP status, response size and latency is logged at the Debug
+// level, stale responses served instead of fresh ones at the Warn level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// discardHandler drops every record, it is the handler of a Client
+// without WithLogger
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// SetOffline turns the offline mode on or off. In offline mode requests are
+// answered from the cache only, stale entries included, and fail with
+// ErrOffline when the response is not cached.
+func (c *Client) SetOffline(offline bool) {
+	c.offline.Store(offline)
+}
+
+// Offline reports whether the offline mode is on
+func (c *Client) Offline() bool {
+	return c.offline.Load()
+}
+
+// BaseURL returns the root URL every request is built from
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Cache returns the cache backing the client
+func (c *Client) Cache() *pokecache.Cache {
+	return c.cache
+}
+
+// url joins a resource path such as "/pokemon/pikachu" onto the base URL
+func (c *Client) url(path string) string {
+	return c.baseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// rebase rewrites an absolute URL returned by the API, such as the next
+// page of a list, onto the base URL of the client. URLs that are not
+// PokeAPI resources are returned unchanged.
+func (c *Client) rebase(rawURL string) string {
+	if rawURL == "" {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	i := strings.Index(u.Path, apiPrefix)
+	if i < 0 {
+		return rawURL
+	}
+	rebased := c.url(u.Path[i+len(apiPrefix):])
+	if u.RawQuery != "" {
+		rebased += "?" + u.RawQuery
+	}
+	return rebased
+}
+
+// get fetches url from the cache or the API and decodes the body into a T.
+// Successful API responses are added to the cache along with the decoded
+// value, so cache hits don't decode the body again. An expired entry that
+// is still cached is revalidated with its ETag or Last-Modified, and only
+// downloaded again if it changed. It is served as stale instead when the
+// API can't be reached, or right away with WithStaleWhileRevalidate.
+// url is canonicalized first, it is the cache key of the response.
+func get[T any](ctx context.Context, c *Client, url string) (T, error) {
+	url = canonicalURL(url)
+	start := time.Now()
+	typed := pokecache.NewTyped(c.cache, decodeJSON[T])
+	val, ok, err := typed.Get(url)
+	if ok && err == nil {
+		c.logger.DebugContext(ctx, "cache hit", "url", url, "latency", time.Since(start))
+		return val, nil
+	}
+	if ok {
+		// an entry that doesn't decode, such as a damaged disk entry or a
+		// bad import, is as good as no entry
+		c.logger.WarnContext(ctx, "dropping cache entry that doesn't decode", "url", url, "err", err)
+		c.cache.Delete(url)
+	}
+	c.logger.DebugContext(ctx, "cache miss", "url", url)
+
+	stale, isStale, err := typed.GetStale(url)
+	isStale = isStale && err == nil
+	if c.offline.Load() {
+		if !isStale {
+			return stale, fmt.Errorf("%w: %s is not cached", ErrOffline, url)
+		}
+		c.logger.WarnContext(ctx, "serving stale response", "url", url, "reason", "offline")
+		return stale, nil
+	}
+	if isStale && c.staleWhileRevalidate {
+		c.logger.WarnContext(ctx, "serving stale response", "url", url, "reason", "refreshing in the background")
+		c.refreshInBackground(ctx, url, func(ctx context.Context) {
+			// on failure the entry stays stale and is refreshed again
+			// on the next request
+			if _, err := refresh(ctx, c, typed, url); err != nil {
+				c.logger.DebugContext(ctx, "background refresh failed", "url", url, "err", err)
+			}
+		})
+		return stale, nil
+	}
+
+	val, err = coalesce(ctx, c, url, func() (T, error) {
+		return refresh(ctx, c, typed, url)
+	})
+	if err != nil && isStale && ctx.Err() == nil && isUnavailable(err) {
+		c.logger.WarnContext(ctx, "serving stale response", "url", url, "reason", "api unavailable", "err", err)
+		return stale, nil
+	}
+	if err == nil {
+		c.logger.DebugContext(ctx, "fetched", "url", url, "latency", time.Since(start))
+	}
+	return val, err
+}
+
+// coalesce runs fetch, unless the same url is already being fetched by
+// another caller, in which case it shares the result of that fetch.
+// A shared fetch cancelled by the caller that started it is run again
+// unless ctx, the context of this caller, is done too.
+func coalesce[T any](ctx context.Context, c *Client, url string, fetch func() (T, error)) (T, error) {
+	val, err, shared := c.flights.do(url, func() (any, error) {
+		return fetch()
+	})
+	if shared && errors.Is(err, context.Canceled) && ctx.Err() == nil {
+		return fetch()
+	}
+	typedVal, ok := val.(T)
+	if !ok && err == nil {
+		// the same url fetched as another type, don't share it
+		return fetch()
+	}
+	return typedVal, err
+}
+
+// refresh gets url from the backend, revalidating the cached entry if
+// there is one, and caches the response
+func refresh[T any](ctx context.Context, c *Client, typed *pokecache.Typed[T], url string) (T, error) {
+	var zero T
+	validators, _ := c.cache.Validators(url)
+	res, err := c.backend.Fetch(ctx, url, validators)
+	if err != nil {
+		return zero, err
+	}
+	if res.NotModified {
+		res.Body.Close()
+		if c.cache.Refresh(url) {
+			if val, ok, err := typed.Get(url); ok {
+				c.logger.DebugContext(ctx, "not modified, cache entry refreshed", "url", url)
+				return val, err
+			}
+		}
+		// the entry was evicted in the meantime, download it again
+		res, err = c.backend.Fetch(ctx, url, pokecache.Validators{})
+		if err != nil {
+			return zero, err
+		}
+	}
+	defer res.Body.Close()
+	start := time.Now()
+	val, raw, err := decodeBody[T](res.Body, c.maxResponseBytes, url)
+	if err != nil {
+		return zero, err
+	}
+	// the body is decoded as it streams in, so this includes reading it
+	c.logger.DebugContext(ctx, "decoded response", "url", url, "bytes", len(raw), "decode_time", time.Since(start))
+	typed.AddWithValidators(url, raw, val, res.Validators)
+	return val, nil
+}
+
+// decodeBody decodes a T from body with a json.Decoder as the body streams
+// in, through a reader that copies what it reads into the bytes returned
+// for the cache, so the body is read only once. With a limit above 0, a
+// body larger than limit bytes fails with an error naming url, like with
+// http.MaxBytesReader.
+func decodeBody[T any](body io.Reader, limit int64, url string) (T, []byte, error) {
+	var val, zero T
+	if limit > 0 {
+		body = http.MaxBytesReader(nil, io.NopCloser(body), limit)
+	}
+	raw := bytes.Buffer{}
+	tee := io.TeeReader(body, &raw)
+	err := json.NewDecoder(tee).Decode(&val)
+	if err == nil {
+		// the rest of the body, such as a final newline, is cached too
+		_, err = io.Copy(io.Discard, tee)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return zero, nil, fmt.Errorf("response for %s is larger than the limit of %d bytes: %w", url, tooLarge.Limit, err)
+	}
+	if err != nil {
+		return zero, nil, err
+	}
+	return val, raw.Bytes(), nil
+}
+
+// decodeJSON decodes a response body into a T
+func decodeJSON[T any](body []byte) (T, error) {
+	var val T
+	err := json.Unmarshal(body, &val)
+	return val, err
+}
+
+// do sends req and returns a successful response, the caller must close
+// its body. Besides 2xx, a 304 Not Modified is successful too.
+// Idempotent requests that fail with a network error, a timeout or a
+// retryable status are sent again according to the retry policy of the
+// client, until the context of req is done.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	maxAttempts := 1
+	if isIdempotent(req) {
+		maxAttempts = c.retry.attempts()
+	}
+
+	reqErr := &RequestError{URL: req.URL.String()}
+	for attempt := 1; ; attempt++ {
+		reqErr.Attempts = attempt
+		var delay time.Duration
+		var hasRetryAfter bool
+
+		if err := c.wait(ctx, reqErr.URL); err != nil {
+			reqErr.StatusCode, reqErr.Body, reqErr.Err = 0, nil, err
+			return nil, reqErr
+		}
+		start := time.Now()
+		res, err := c.attempt(req)
+		if err == nil {
+			c.logger.DebugContext(ctx, "http response", "url", reqErr.URL, "status", res.StatusCode,
+				"attempt", attempt, "latency", time.Since(start))
+			if res.StatusCode <= 299 || res.StatusCode == http.StatusNotModified {
+				if attempt > 1 {
+					c.logger.InfoContext(ctx, "request succeeded after retries", "url", reqErr.URL, "attempts", attempt)
+				}
+				return res, nil
+			}
+			reqErr.StatusCode, reqErr.Body, reqErr.Err = res.StatusCode, readErrorBody(res), nil
+			if !isRetryableStatus(res.StatusCode) {
+				return nil, reqErr
+			}
+			delay, hasRetryAfter = retryAfter(res.Header.Get("Retry-After"), time.Now())
+		} else {
+			c.logger.DebugContext(ctx, "http request failed", "url", reqErr.URL, "attempt", attempt,
+				"latency", time.Since(start), "err", err)
+			reqErr.StatusCode, reqErr.Body, reqErr.Err = 0, nil, err
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil {
+			return nil, reqErr
+		}
+		if hasRetryAfter {
+			delay = c.retry.cap(delay)
+		} else {
+			delay = c.retry.backoff(attempt)
+		}
+		c.logger.DebugContext(ctx, "retrying", "url", reqErr.URL, "delay", delay)
+		if err := c.sleep(ctx, delay); err != nil {
+			reqErr.StatusCode, reqErr.Body, reqErr.Err = 0, nil, err
+			return nil, reqErr
+		}
+	}
+}
+
+// attempt sends req once, within the timeout of the client. The timeout
+// covers reading the body too, it ends once the body is closed.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	res, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = cancelOnClose{res.Body, cancel}
+	return res, nil
+}
+
+// cancelOnClose is a response body that cancels the context of its request
+// once closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// readErrorBody reads and closes the body of a failed response, keeping
+// at most maxErrorBody bytes
+func readErrorBody(res *http.Response) []byte {
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	return body
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
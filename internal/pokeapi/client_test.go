@@ -0,0 +1,262 @@
+package pokeapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// newTestServer serves a fixed body for every path in bodies and counts
+// the requests that reach it
+func newTestServer(t *testing.T, bodies map[string]string) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// newTestCache creates a cache that is closed once the test ends
+func newTestCache(t *testing.T) *pokecache.Cache {
+	t.Helper()
+	cache := pokecache.NewCache(time.Minute)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestGetPokemon(t *testing.T) {
+	server, calls := newTestServer(t, map[string]string{
+		"/pokemon/pikachu/": `{"name":"pikachu","id":25,"base_experience":112}`,
+	})
+	client := NewClient(newTestCache(t), server.URL)
+
+	cases := []string{"pikachu", "Pikachu"}
+	for i, name := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			pokemon, err := client.GetPokemon(context.Background(), name)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if pokemon.Name != "pikachu" || pokemon.ID != 25 || pokemon.BaseExperience != 112 {
+				t.Errorf("unexpected pokemon: %+v", pokemon)
+				return
+			}
+		})
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 call to the server, got %d", *calls)
+	}
+}
+
+func TestGetPokemonUndecodableEntry(t *testing.T) {
+	server, calls := newTestServer(t, map[string]string{
+		"/pokemon/pikachu/": `{"name":"pikachu","id":25}`,
+	})
+	client := NewClient(newTestCache(t), server.URL)
+	client.Cache().Add(server.URL+"/pokemon/pikachu/", []byte("{not json"))
+
+	for i := 0; i < 2; i++ {
+		pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+		if err != nil || pokemon.Name != "pikachu" {
+			t.Fatalf("expected pikachu, got %+v, %v", pokemon, err)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("expected the entry to be fetched again once, got %d calls", *calls)
+	}
+}
+
+func TestListResources(t *testing.T) {
+	server, calls := newTestServer(t, map[string]string{
+		"/location-area/": `{"count":2,"next":"next-page","results":[{"name":"canalave-city-area"},{"name":"eterna-city-area"}]}`,
+	})
+	client := NewClient(newTestCache(t), server.URL+"/")
+
+	for i := 0; i < 2; i++ {
+		locationAreas, err := client.ListResources(context.Background(), "location-area", "", 0)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		if len(locationAreas.Results) != 2 || locationAreas.Next != "next-page" {
+			t.Errorf("unexpected page: %+v", locationAreas)
+			return
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 call to the server, got %d", *calls)
+	}
+}
+
+func TestGetLocationAreaNotFound(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{})
+	client := NewClient(newTestCache(t), server.URL)
+
+	_, err := client.GetLocationArea(context.Background(), "nowhere")
+	if err == nil {
+		t.Errorf("expected an error")
+		return
+	}
+	if _, ok := client.Cache().Get(server.URL + "/location-area/nowhere/"); ok {
+		t.Errorf("expected failed response to not be cached")
+	}
+}
+
+func TestListResourcesRebasesCursors(t *testing.T) {
+	server, calls := newTestServer(t, map[string]string{
+		"/location-area/": `{"next":"https://pokeapi.co/api/v2/location-area/?offset=20&limit=20","previous":null}`,
+	})
+	client := NewClient(newTestCache(t), server.URL)
+
+	locationAreas, err := client.ListResources(context.Background(), "location-area", "", 0)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	expected := server.URL + "/location-area/?offset=20&limit=20"
+	if locationAreas.Next != expected {
+		t.Errorf("expected next to be %s, got %s", expected, locationAreas.Next)
+		return
+	}
+	if locationAreas.Previous != "" {
+		t.Errorf("expected no previous page, got %s", locationAreas.Previous)
+		return
+	}
+
+	// a cursor from the public API is fetched from the test server
+	publicURL := "https://pokeapi.co/api/v2/location-area/"
+	if _, err := client.ListResources(context.Background(), "location-area", publicURL, 0); err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 call to the server, got %d", *calls)
+	}
+}
+
+// newHangingServer never answers until the request is cancelled
+func newHangingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTimeout(t *testing.T) {
+	server := newHangingServer(t)
+	client := NewClient(newTestCache(t), server.URL,
+		WithTimeout(10*time.Millisecond), WithRetryPolicy(NoRetry))
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	server := newHangingServer(t)
+	client := NewClient(newTestCache(t), server.URL, WithTimeout(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := client.GetPokemon(ctx, "pikachu")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a canceled error, got %v", err)
+		return
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) && reqErr.Attempts != 1 {
+		t.Errorf("expected no retries after cancel, got %d attempts", reqErr.Attempts)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	server, calls := newTestServer(t, map[string]string{
+		"/pokemon/pikachu/": `{"name":"pikachu","id":25}`,
+		"/pokemon/mew/":     `{"name":"mew","id":151}`,
+	})
+	clock := &manualClock{now: time.Now()}
+	cache := pokecache.NewCache(time.Minute,
+		pokecache.WithClock(clock),
+		pokecache.WithStaleRetention(time.Hour))
+	defer cache.Close()
+	client := NewClient(cache, server.URL)
+	ctx := context.Background()
+
+	if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.SetOffline(true)
+
+	if pokemon, err := client.GetPokemon(ctx, "pikachu"); err != nil || pokemon.Name != "pikachu" {
+		t.Errorf("expected the cached pikachu, got %+v, %v", pokemon, err)
+	}
+	if _, err := client.GetPokemon(ctx, "mew"); !errors.Is(err, ErrOffline) {
+		t.Errorf("expected ErrOffline, got %v", err)
+	}
+
+	// expired entries are served too
+	clock.Advance(2 * time.Minute)
+	if pokemon, err := client.GetPokemon(ctx, "pikachu"); err != nil || pokemon.Name != "pikachu" {
+		t.Errorf("expected the stale pikachu, got %+v, %v", pokemon, err)
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 call to the server, got %d", *calls)
+	}
+
+	client.SetOffline(false)
+	if _, err := client.GetPokemon(ctx, "mew"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{
+		"/pokemon/pikachu/": `{"name":"pikachu","id":25}`,
+	})
+	logs := bytes.Buffer{}
+	client := NewClient(newTestCache(t), server.URL,
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := []string{
+		`msg="cache miss" url=` + server.URL + "/pokemon/pikachu/",
+		`msg="http response" url=` + server.URL + "/pokemon/pikachu/ status=200 attempt=1 latency=",
+		`msg="decoded response" url=` + server.URL + "/pokemon/pikachu/ bytes=26 decode_time=",
+		`msg=fetched url=` + server.URL + "/pokemon/pikachu/ latency=",
+		`msg="cache hit" url=` + server.URL + "/pokemon/pikachu/ latency=",
+	}
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d log lines, got:\n%s", len(expected), logs.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, expected[i]) {
+			t.Errorf("expected log line %d to contain %s, got %s", i, expected[i], line)
+		}
+	}
+}
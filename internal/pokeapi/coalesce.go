@@ -0,0 +1,47 @@
+package pokeapi
+
+import "sync"
+
+// flightGroup coalesces concurrent calls with the same key into one: the
+// first caller runs the call, the others wait for it and share its result.
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a call in progress, or done once wg is done
+type flight struct {
+	wg   sync.WaitGroup
+	dups int // callers waiting for the call, guarded by the group mutex
+	val  any
+	err  error
+}
+
+// do runs fn unless a call with the same key is already running, in which
+// case it waits for that call and returns its result. shared reports
+// whether the result was shared with other callers.
+func (g *flightGroup) do(key string, fn func() (any, error)) (val any, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	if f, ok := g.calls[key]; ok {
+		f.dups++
+		g.mutex.Unlock()
+		f.wg.Wait()
+		return f.val, f.err, true
+	}
+	f := &flight{}
+	f.wg.Add(1)
+	g.calls[key] = f
+	g.mutex.Unlock()
+
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		f.wg.Done()
+	}()
+	f.val, f.err = fn()
+	return f.val, f.err, false
+}
@@ -0,0 +1,123 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// waitForDups waits until n callers wait for the call in flight for key
+func waitForDups(t *testing.T, g *flightGroup, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mutex.Lock()
+		f, ok := g.calls[key]
+		dups := 0
+		if ok {
+			dups = f.dups
+		}
+		g.mutex.Unlock()
+		if dups == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d callers to wait for %s", n, key)
+}
+
+func TestCoalesce(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		fmt.Fprint(w, `{"name":"pikachu","id":25}`)
+	}))
+	defer server.Close()
+
+	adds := atomic.Int32{}
+	cache := pokecache.NewCache(time.Minute)
+	defer cache.Close()
+	cache.OnAdd(func(key string, val []byte) {
+		adds.Add(1)
+	})
+	client := NewClient(cache, server.URL)
+
+	const callers = 10
+	errs := make(chan error, callers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+			if err == nil && pokemon.Name != "pikachu" {
+				err = fmt.Errorf("unexpected pokemon: %+v", pokemon)
+			}
+			errs <- err
+		}()
+	}
+	waitForDups(t, &client.flights, server.URL+"/pokemon/pikachu/", callers-1)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+	if n := adds.Load(); n != 1 {
+		t.Errorf("expected 1 cache add, got %d", n)
+	}
+}
+
+func TestCoalesceCancelled(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-release
+		}
+		fmt.Fprint(w, `{"name":"pikachu","id":25}`)
+	}))
+	defer server.Close()
+	defer close(release)
+	client := NewClient(newTestCache(t), server.URL, WithRetryPolicy(NoRetry))
+
+	// the caller that started the request gives up, the other one carries on
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.GetPokemon(ctx, "pikachu")
+		first <- err
+	}()
+	second := make(chan error, 1)
+	go func() {
+		for requests.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		_, err := client.GetPokemon(context.Background(), "pikachu")
+		second <- err
+	}()
+	waitForDups(t, &client.flights, server.URL+"/pokemon/pikachu/", 1)
+	cancel()
+
+	if err := <-first; err == nil {
+		t.Errorf("expected the first caller to be cancelled")
+	}
+	if err := <-second; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
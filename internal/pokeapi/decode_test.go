@@ -0,0 +1,48 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBody(t *testing.T) {
+	body := "{\"name\":\"pikachu\",\"id\":25}\n"
+	pokemon, raw, err := decodeBody[Pokemon](strings.NewReader(body), 0, "pikachu")
+	if err != nil || pokemon.Name != "pikachu" || pokemon.ID != 25 {
+		t.Fatalf("expected pikachu, got %+v, %v", pokemon, err)
+	}
+	if string(raw) != body {
+		t.Errorf("expected the whole body to be kept, got %q", raw)
+	}
+
+	if _, _, err := decodeBody[Pokemon](strings.NewReader(`{"name":`), 0, "pikachu"); err == nil {
+		t.Errorf("expected an error for a truncated body")
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{
+		"/pokemon/pikachu/": `{"name":"pikachu","id":25,"moves":[` + strings.Repeat(`{},`, 100) + `{}]}`,
+		"/pokemon/mew/":     `{"name":"mew","id":151}`,
+	})
+	client := NewClient(newTestCache(t), server.URL, WithMaxResponseBytes(100))
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 100 {
+		t.Fatalf("expected a max bytes error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), server.URL+"/pokemon/pikachu/") {
+		t.Errorf("expected the error to name the resource, got %v", err)
+	}
+	if _, ok := client.Cache().Get(server.URL + "/pokemon/pikachu/"); ok {
+		t.Errorf("expected the response to not be cached")
+	}
+
+	if _, err := client.GetPokemon(context.Background(), "mew"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
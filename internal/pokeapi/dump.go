@@ -0,0 +1,217 @@
+package pokeapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// defaultPageSize is the page size of the PokeAPI when no limit is given
+const defaultPageSize = 20
+
+// DumpBackend reads the PokeAPI from a copy of its static data on disk,
+// such as a checkout of PokeAPI/api-data, where every resource is an
+// index.json file: api/v2/pokemon/25/index.json for Pokemon 25 and
+// api/v2/pokemon/index.json for the full list of Pokemon. The dump only
+// has resources by id, names are resolved through the lists, and lists
+// are paged with offset and limit like the API does.
+type DumpBackend struct {
+	dir   string
+	mutex sync.Mutex
+	lists map[string]dumpList // full lists read so far, by resource kind
+}
+
+// dumpList is the full list of a resource kind as stored in the dump
+type dumpList struct {
+	Results []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"results"`
+}
+
+// constructor for DumpBackend
+// dir: directory holding the api/v2 tree of the dump
+func NewDumpBackend(dir string) (*DumpBackend, error) {
+	info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(apiPrefix)))
+	if err != nil {
+		return nil, fmt.Errorf("no PokeAPI dump in %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("no PokeAPI dump in %s: %s is not a directory", dir, apiPrefix)
+	}
+	return &DumpBackend{
+		dir:   dir,
+		lists: make(map[string]dumpList),
+	}, nil
+}
+
+// Fetch reads the resource at rawURL from the dump. Only the path and the
+// query of rawURL are used. Validators are ignored, the dump has none.
+func (b *DumpBackend) Fetch(ctx context.Context, rawURL string, validators pokecache.Validators) (Response, error) {
+	reqErr := &RequestError{URL: rawURL, Attempts: 1}
+	if err := ctx.Err(); err != nil {
+		reqErr.Err = err
+		return Response{}, reqErr
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		reqErr.Err = err
+		return Response{}, reqErr
+	}
+	i := strings.Index(u.Path, apiPrefix)
+	if i < 0 {
+		return Response{}, b.notFound(reqErr, u.Path)
+	}
+	resource := strings.Trim(u.Path[i+len(apiPrefix):], "/")
+
+	var body io.ReadCloser
+	kind, name, isSingle := strings.Cut(resource, "/")
+	_, idErr := strconv.Atoi(name)
+	switch {
+	case !isSingle:
+		body, err = b.page(u, resource)
+	case idErr != nil && !strings.Contains(name, "/"):
+		body, err = b.byName(kind, name)
+	default:
+		// by id, or a sub-resource such as pokemon/25/encounters
+		body, err = b.open(resource)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return Response{}, b.notFound(reqErr, resource)
+	}
+	if err != nil {
+		reqErr.Err = err
+		return Response{}, reqErr
+	}
+	return Response{Body: body}, nil
+}
+
+// notFound turns reqErr into the error of a resource missing from the dump
+func (b *DumpBackend) notFound(reqErr *RequestError, resource string) *RequestError {
+	reqErr.StatusCode = http.StatusNotFound
+	reqErr.Err = fmt.Errorf("%s is not in the PokeAPI dump in %s", resource, b.dir)
+	return reqErr
+}
+
+// path returns the path of the index.json of resource, a path such as
+// "pokemon/25"
+func (b *DumpBackend) path(resource string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(apiPrefix+resource), "index.json")
+}
+
+// open opens the index.json of resource
+func (b *DumpBackend) open(resource string) (io.ReadCloser, error) {
+	return os.Open(b.path(resource))
+}
+
+// byName reads the resource of kind named name, looking its id up in the
+// list of kind
+func (b *DumpBackend) byName(kind, name string) (io.ReadCloser, error) {
+	list, err := b.list(kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range list.Results {
+		if result.Name == name {
+			return b.open(kind + "/" + path.Base(result.URL))
+		}
+	}
+	return nil, fs.ErrNotExist
+}
+
+// list returns the full list of kind, read once
+func (b *DumpBackend) list(kind string) (dumpList, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if list, ok := b.lists[kind]; ok {
+		return list, nil
+	}
+	data, err := os.ReadFile(b.path(kind))
+	if err != nil {
+		return dumpList{}, err
+	}
+	list := dumpList{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return dumpList{}, fmt.Errorf("reading the list of %s: %w", kind, err)
+	}
+	b.lists[kind] = list
+	return list, nil
+}
+
+// page returns the page of the list of kind selected by the offset and
+// limit of u, shaped like a page of the API, with next and previous URLs
+// built from u
+func (b *DumpBackend) page(u *url.URL, kind string) (io.ReadCloser, error) {
+	list, err := b.list(kind)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	offset, err := queryInt(query, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := queryInt(query, "limit", defaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+
+	count := len(list.Results)
+	start, end := min(offset, count), min(offset+limit, count)
+	pageURL := func(offset int) *string {
+		page := *u
+		page.RawQuery = url.Values{
+			"offset": {strconv.Itoa(offset)},
+			"limit":  {strconv.Itoa(limit)},
+		}.Encode()
+		s := page.String()
+		return &s
+	}
+	var next, previous *string
+	if end < count {
+		next = pageURL(end)
+	}
+	if start > 0 {
+		previous = pageURL(max(0, start-limit))
+	}
+	data, err := json.Marshal(map[string]any{
+		"count":    count,
+		"next":     next,
+		"previous": previous,
+		"results":  list.Results[start:end],
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// queryInt parses the non negative integer parameter name of query
+func queryInt(query url.Values, name string, fallback int) (int, error) {
+	val := query.Get(name)
+	if val == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return n, nil
+}
@@ -0,0 +1,98 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newDumpClient writes files, by path under api/v2, to a PokeAPI dump and
+// returns a client reading from it
+func newDumpClient(t *testing.T, files map[string]string) *Client {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, "api", "v2", filepath.FromSlash(name), "index.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend, err := NewDumpBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(newTestCache(t), "", WithBackend(backend))
+}
+
+func TestDumpGetPokemon(t *testing.T) {
+	client := newDumpClient(t, map[string]string{
+		"pokemon":    `{"count":2,"results":[{"name":"bulbasaur","url":"/api/v2/pokemon/1/"},{"name":"pikachu","url":"/api/v2/pokemon/25/"}]}`,
+		"pokemon/25": `{"name":"pikachu","id":25}`,
+	})
+	ctx := context.Background()
+
+	for _, name := range []string{"25", "pikachu"} {
+		pokemon, err := client.GetPokemon(ctx, name)
+		if err != nil || pokemon.Name != "pikachu" || pokemon.ID != 25 {
+			t.Errorf("%s: expected pikachu, got %+v, %v", name, pokemon, err)
+		}
+	}
+
+	// listed but missing from the dump, and not listed at all
+	for _, name := range []string{"bulbasaur", "mew"} {
+		_, err := client.GetPokemon(ctx, name)
+		reqErr := &RequestError{}
+		if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected a not found error, got %v", name, err)
+		}
+	}
+}
+
+func TestDumpListResources(t *testing.T) {
+	results := []string{}
+	for i := 1; i <= 25; i++ {
+		results = append(results, fmt.Sprintf(`{"name":"area-%d","url":"/api/v2/location-area/%d/"}`, i, i))
+	}
+	client := newDumpClient(t, map[string]string{
+		"location-area": `{"count":25,"next":null,"previous":null,"results":[` + strings.Join(results, ",") + `]}`,
+	})
+	ctx := context.Background()
+
+	first, err := client.ListResources(ctx, "location-area", "", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Results) != 20 || first.Results[0].Name != "area-1" || first.Previous != "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	expected := DefaultBaseURL + "/location-area/?limit=20&offset=20"
+	if first.Next != expected {
+		t.Fatalf("expected next to be %s, got %s", expected, first.Next)
+	}
+
+	second, err := client.ListResources(ctx, "location-area", first.Next, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(second.Results) != 5 || second.Results[0].Name != "area-21" || second.Next != "" {
+		t.Errorf("unexpected second page: %+v", second)
+	}
+	expected = DefaultBaseURL + "/location-area/?limit=20&offset=0"
+	if second.Previous != expected {
+		t.Errorf("expected previous to be %s, got %s", expected, second.Previous)
+	}
+}
+
+func TestNewDumpBackendMissing(t *testing.T) {
+	if _, err := NewDumpBackend(t.TempDir()); err == nil {
+		t.Errorf("expected an error for a directory without a dump")
+	}
+}
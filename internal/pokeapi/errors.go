@@ -0,0 +1,35 @@
+package pokeapi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOffline is returned in offline mode for responses that are not cached
+var ErrOffline = errors.New("offline mode")
+
+// RequestError is returned when a request still fails after every retry
+type RequestError struct {
+	URL        string
+	Attempts   int    // number of times the request was sent
+	StatusCode int    // status of the last response, 0 when none was received
+	Body       []byte // body of the last response
+	Err        error  // transport error of the last attempt, nil when a response was received
+}
+
+func (e *RequestError) Error() string {
+	var msg string
+	if e.Err != nil {
+		msg = fmt.Sprintf("request to %s failed: %v", e.URL, e.Err)
+	} else {
+		msg = fmt.Sprintf("response failed with status code: %d and\nbody: %s", e.StatusCode, e.Body)
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf("\n(gave up after %d attempts)", e.Attempts)
+	}
+	return msg
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,93 @@
+package pokeapi
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// ListResources gets a page of the list of resource, such as "pokemon" or
+// "location-area". The Next and Previous cursors are rewritten onto the
+// base URL.
+// pageURL: URL of the page to get, empty gets the first page
+// pageSize: results per page of the first page, 0 uses the API default;
+// later pages keep the size of the page their cursor comes from
+func (c *Client) ListResources(ctx context.Context, resource, pageURL string, pageSize int) (NamedAPIResourceList, error) {
+	u := c.url("/" + resource + "/")
+	if pageURL != "" {
+		u = c.rebase(pageURL)
+	} else if pageSize > 0 {
+		u += "?" + url.Values{"limit": {strconv.Itoa(pageSize)}}.Encode()
+	}
+
+	list, err := get[NamedAPIResourceList](ctx, c, u)
+	list.Next = c.rebase(list.Next)
+	list.Previous = c.rebase(list.Previous)
+	return list, err
+}
+
+// Pages walks every page of the list of resource, from the first one.
+// An error is yielded once, and ends the walk.
+func (c *Client) Pages(ctx context.Context, resource string, pageSize int) iter.Seq2[NamedAPIResourceList, error] {
+	return func(yield func(NamedAPIResourceList, error) bool) {
+		pageURL := ""
+		for {
+			page, err := c.ListResources(ctx, resource, pageURL, pageSize)
+			if err != nil {
+				yield(NamedAPIResourceList{}, err)
+				return
+			}
+			if !yield(page, nil) || page.Next == "" {
+				return
+			}
+			pageURL = page.Next
+		}
+	}
+}
+
+// Resources walks every resource in the list of resource, fetching the
+// pages pageSize results at a time as needed. An error is yielded once,
+// and ends the walk.
+func (c *Client) Resources(ctx context.Context, resource string, pageSize int) iter.Seq2[NamedAPIResource, error] {
+	return func(yield func(NamedAPIResource, error) bool) {
+		for page, err := range c.Pages(ctx, resource, pageSize) {
+			if err != nil {
+				yield(NamedAPIResource{}, err)
+				return
+			}
+			for _, result := range page.Results {
+				if !yield(result, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// TTLPolicy returns a cache TTLPolicy for the responses of a Client: single
+// resources, such as "/pokemon/pikachu/", are kept for resourceTTL, and
+// pages of the list of any resource, such as "/pokemon/?limit=20", for
+// listTTL. Keys that are not PokeAPI URLs get listTTL too.
+func TTLPolicy(resourceTTL, listTTL time.Duration) pokecache.TTLPolicy {
+	return func(key string) time.Duration {
+		u, err := url.Parse(key)
+		if err != nil {
+			return listTTL
+		}
+		i := strings.Index(u.Path, apiPrefix)
+		if i < 0 {
+			return listTTL
+		}
+		// "pokemon" for a list page, "pokemon/pikachu" for a resource
+		resource := strings.Trim(u.Path[i+len(apiPrefix):], "/")
+		if strings.Contains(resource, "/") {
+			return resourceTTL
+		}
+		return listTTL
+	}
+}
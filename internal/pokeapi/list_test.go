@@ -0,0 +1,89 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPokemonListClient(t *testing.T, n int) *Client {
+	t.Helper()
+	results := []string{}
+	for i := 1; i <= n; i++ {
+		results = append(results, fmt.Sprintf(`{"name":"pokemon-%d","url":"/api/v2/pokemon/%d/"}`, i, i))
+	}
+	return newDumpClient(t, map[string]string{
+		"pokemon": `{"count":5,"results":[` + strings.Join(results, ",") + `]}`,
+	})
+}
+
+func TestPages(t *testing.T) {
+	client := newPokemonListClient(t, 5)
+
+	sizes := []int{}
+	for page, err := range client.Pages(context.Background(), "pokemon", 2) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sizes = append(sizes, len(page.Results))
+	}
+	if fmt.Sprint(sizes) != "[2 2 1]" {
+		t.Errorf("expected pages of 2, 2 and 1 results, got %v", sizes)
+	}
+}
+
+func TestResources(t *testing.T) {
+	client := newPokemonListClient(t, 5)
+
+	names := []string{}
+	for resource, err := range client.Resources(context.Background(), "pokemon", 2) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		names = append(names, resource.Name)
+		if len(names) == 3 {
+			break
+		}
+	}
+	if strings.Join(names, ",") != "pokemon-1,pokemon-2,pokemon-3" {
+		t.Errorf("unexpected resources: %v", names)
+	}
+	// the walk stopped with the second page
+	if stats := client.Cache().Stats(); stats.Entries != 2 {
+		t.Errorf("expected 2 pages to be fetched, got %d", stats.Entries)
+	}
+
+	errs := 0
+	for _, err := range client.Resources(context.Background(), "move", 2) {
+		if err == nil {
+			t.Fatalf("expected an error for a missing list")
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("expected a single error, got %d", errs)
+	}
+}
+
+func TestTTLPolicy(t *testing.T) {
+	policy := TTLPolicy(time.Hour, time.Minute)
+	cases := []struct {
+		key      string
+		expected time.Duration
+	}{
+		{"https://pokeapi.co/api/v2/pokemon/pikachu/", time.Hour},
+		{"https://pokeapi.co/api/v2/location-area/canalave-city-area/", time.Hour},
+		{"https://pokeapi.co/api/v2/pokemon/", time.Minute},
+		{"https://pokeapi.co/api/v2/pokemon/?limit=20&offset=20", time.Minute},
+		{"https://pokeapi.co/api/v2/location-area/?limit=20", time.Minute},
+		{"http://localhost:8000/api/v2/item/?limit=20", time.Minute},
+		{"https://example.com/pokemon/pikachu/", time.Minute},
+	}
+	for _, c := range cases {
+		if actual := policy(c.key); actual != c.expected {
+			t.Errorf("TTL of %s: expected %v, got %v", c.key, c.expected, actual)
+		}
+	}
+}
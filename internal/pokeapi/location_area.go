@@ -0,0 +1,13 @@
+package pokeapi
+
+import "context"
+
+// GetLocationArea gets a single location area by name or id
+func (c *Client) GetLocationArea(ctx context.Context, name string) (LocationAreasExplore, error) {
+	url := canonicalURL(c.url("/location-area/" + name))
+	area, err := get[LocationAreasExplore](ctx, c, url)
+	if err == nil {
+		c.alias(url, "/location-area/", area.Name, area.ID)
+	}
+	return area, err
+}
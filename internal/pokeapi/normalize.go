@@ -0,0 +1,47 @@
+package pokeapi
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// canonicalURL rewrites equivalent URLs of a resource to a single form, so
+// they share one cache entry: lowercase scheme and host, a clean path with
+// the trailing slash the PokeAPI uses itself, and the query sorted by key.
+// URLs that don't parse are returned as is.
+func canonicalURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	p := path.Clean("/" + u.Path)
+	if p != "/" {
+		p += "/"
+	}
+	u.Path, u.RawPath = p, ""
+	u.RawQuery, u.ForceQuery = u.Query().Encode(), false
+	u.Fragment = ""
+	return u.String()
+}
+
+// CacheKey returns the key the response for rawURL is cached under, so
+// any of the equivalent URLs of a resource finds its cache entry
+func CacheKey(rawURL string) string {
+	return canonicalURL(rawURL)
+}
+
+// alias lets the cache entry at key, a resource of kind fetched by name or
+// by id, be found by the other one as well, so it is not downloaded twice
+// kind: path of the resource list, for example "/pokemon/"
+func (c *Client) alias(key, kind, name string, id int) {
+	if name != "" {
+		c.cache.Alias(canonicalURL(c.url(kind+name)), key)
+	}
+	if id > 0 {
+		c.cache.Alias(canonicalURL(c.url(kind+strconv.Itoa(id))), key)
+	}
+}
@@ -0,0 +1,55 @@
+package pokeapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"https://pokeapi.co/api/v2/pokemon/pikachu", "https://pokeapi.co/api/v2/pokemon/pikachu/"},
+		{"https://pokeapi.co/api/v2/pokemon/pikachu/", "https://pokeapi.co/api/v2/pokemon/pikachu/"},
+		{"HTTPS://PokeAPI.co/api/v2//pokemon/./pikachu", "https://pokeapi.co/api/v2/pokemon/pikachu/"},
+		{"https://pokeapi.co/api/v2/location-area/?offset=20&limit=20", "https://pokeapi.co/api/v2/location-area/?limit=20&offset=20"},
+		{"https://pokeapi.co/api/v2/location-area?limit=20&offset=20", "https://pokeapi.co/api/v2/location-area/?limit=20&offset=20"},
+		{"https://pokeapi.co/api/v2/location-area/?", "https://pokeapi.co/api/v2/location-area/"},
+		{"https://pokeapi.co", "https://pokeapi.co/"},
+	}
+	for _, c := range cases {
+		if actual := canonicalURL(c.input); actual != c.expected {
+			t.Errorf("canonicalURL(%q) = %q, expected %q", c.input, actual, c.expected)
+		}
+		if actual := CacheKey(c.input); actual != c.expected {
+			t.Errorf("CacheKey(%q) = %q, expected %q", c.input, actual, c.expected)
+		}
+	}
+}
+
+func TestAliasIDAndName(t *testing.T) {
+	server, calls := newTestServer(t, map[string]string{
+		"/pokemon/pikachu/": `{"name":"pikachu","id":25}`,
+		"/pokemon/151/":     `{"name":"mew","id":151}`,
+	})
+	cache := pokecache.NewCache(time.Minute)
+	defer cache.Close()
+	client := NewClient(cache, server.URL)
+	ctx := context.Background()
+
+	for _, name := range []string{"pikachu", "25", "pikachu/", "151", "Mew"} {
+		if _, err := client.GetPokemon(ctx, name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("expected 2 calls to the server, got %d", *calls)
+	}
+	if stats := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("expected 2 entries, got %d", stats.Entries)
+	}
+}
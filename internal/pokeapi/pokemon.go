@@ -0,0 +1,16 @@
+package pokeapi
+
+import (
+	"context"
+	"strings"
+)
+
+// GetPokemon gets a single Pokemon by name or id
+func (c *Client) GetPokemon(ctx context.Context, name string) (Pokemon, error) {
+	url := canonicalURL(c.url("/pokemon/" + strings.ToLower(name)))
+	pokemon, err := get[Pokemon](ctx, c, url)
+	if err == nil {
+		c.alias(url, "/pokemon/", pokemon.Name, pokemon.ID)
+	}
+	return pokemon, err
+}
@@ -0,0 +1,86 @@
+package pokeapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket shared by every request of a Client: it holds
+// up to burst tokens, refilled at rate tokens per second, and every
+// attempt of a request takes one
+type limiter struct {
+	mutex  sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // capacity of the bucket
+	tokens float64 // tokens left, negative when waits are already queued
+	last   time.Time
+	now    func() time.Time // replaced in tests
+}
+
+func newLimiter(perSecond float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (l *limiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back a token reserved but not used
+func (l *limiter) cancel() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// WithRateLimit limits the requests sent by the client, across every
+// goroutine using it, to perSecond on average with bursts of up to burst
+// requests. Retries count as requests. perSecond of 0 or less disables the
+// limit, which is the default.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newLimiter(perSecond, burst)
+	}
+}
+
+// wait blocks until the rate limit allows to send a request to url, or ctx
+// is done
+func (c *Client) wait(ctx context.Context, url string) error {
+	if c.limiter == nil {
+		return nil
+	}
+	delay := c.limiter.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	c.logger.DebugContext(ctx, "rate limit wait", "url", url, "delay", delay.Round(time.Millisecond))
+	if err := c.sleep(ctx, delay); err != nil {
+		c.limiter.cancel()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pokeapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newLimiter(10, 2)
+	l.now = func() time.Time { return now }
+
+	cases := []struct {
+		advance  time.Duration
+		expected time.Duration
+	}{
+		// the burst is free
+		{0, 0},
+		{0, 0},
+		// then one request every 100ms, waits queue up
+		{0, 100 * time.Millisecond},
+		{0, 200 * time.Millisecond},
+		// after a while the bucket is full again, but not fuller
+		{time.Second, 0},
+		{0, 0},
+		{0, 100 * time.Millisecond},
+	}
+	for i, c := range cases {
+		now = now.Add(c.advance)
+		if actual := l.reserve(); actual != c.expected {
+			t.Errorf("reservation %d: expected to wait %v, got %v", i, c.expected, actual)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	bodies := map[string]string{}
+	for i := 1; i <= 4; i++ {
+		bodies[fmt.Sprintf("/pokemon/%d/", i)] = fmt.Sprintf(`{"id":%d}`, i)
+	}
+	server, calls := newTestServer(t, bodies)
+
+	debug := bytes.Buffer{}
+	client := NewClient(newTestCache(t), server.URL,
+		WithRateLimit(10, 2),
+		WithLogger(slog.New(slog.NewTextHandler(&debug, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	now := time.Now()
+	client.limiter.now = func() time.Time { return now }
+	delays := []time.Duration{}
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	for i := 1; i <= 4; i++ {
+		if _, err := client.GetPokemon(context.Background(), fmt.Sprint(i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if *calls != 4 {
+		t.Errorf("expected 4 calls to the server, got %d", *calls)
+	}
+	if len(delays) != 2 || delays[0] != 100*time.Millisecond || delays[1] != 100*time.Millisecond {
+		t.Errorf("expected to wait twice 100ms, got %v", delays)
+	}
+	if n := strings.Count(debug.String(), `msg="rate limit wait"`); n != 2 {
+		t.Errorf("expected 2 waits in the debug log, got:\n%s", debug.String())
+	}
+
+	// cached responses don't count
+	if _, err := client.GetPokemon(context.Background(), "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(delays) != 2 {
+		t.Errorf("expected no wait for a cached response, got %v", delays)
+	}
+}
@@ -0,0 +1,202 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type scriptedResponse struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// newScriptedServer answers the nth request with the nth scripted response
+// and keeps repeating the last one
+func newScriptedServer(t *testing.T, script []scriptedResponse) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := script[min(calls, len(script)-1)]
+		calls++
+		if res.retryAfter != "" {
+			w.Header().Set("Retry-After", res.retryAfter)
+		}
+		w.WriteHeader(res.status)
+		fmt.Fprint(w, res.body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// newRetryClient returns a client that records its delays instead of sleeping
+func newRetryClient(t *testing.T, baseURL string, policy RetryPolicy) (*Client, *[]time.Duration) {
+	delays := []time.Duration{}
+	client := NewClient(newTestCache(t), baseURL, WithRetryPolicy(policy))
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return client, &delays
+}
+
+func TestRetry(t *testing.T) {
+	const pikachu = `{"name":"pikachu"}`
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	cases := []struct {
+		name         string
+		script       []scriptedResponse
+		wantErr      bool
+		wantStatus   int
+		wantAttempts int
+	}{
+		{
+			name:         "success",
+			script:       []scriptedResponse{{status: 200, body: pikachu}},
+			wantAttempts: 1,
+		},
+		{
+			name: "recovers after transient failures",
+			script: []scriptedResponse{
+				{status: 502},
+				{status: 503},
+				{status: 200, body: pikachu},
+			},
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after max attempts",
+			script:       []scriptedResponse{{status: 502}},
+			wantErr:      true,
+			wantStatus:   502,
+			wantAttempts: 3,
+		},
+		{
+			name:         "does not retry client errors",
+			script:       []scriptedResponse{{status: 404}},
+			wantErr:      true,
+			wantStatus:   404,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, calls := newScriptedServer(t, c.script)
+			client, delays := newRetryClient(t, server.URL, policy)
+
+			_, err := client.GetPokemon(context.Background(), "pikachu")
+			if *calls != c.wantAttempts {
+				t.Errorf("expected %d calls, got %d", c.wantAttempts, *calls)
+			}
+			if len(*delays) != c.wantAttempts-1 {
+				t.Errorf("expected %d delays, got %v", c.wantAttempts-1, *delays)
+			}
+			if !c.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var reqErr *RequestError
+			if !errors.As(err, &reqErr) {
+				t.Errorf("expected a RequestError, got %v", err)
+				return
+			}
+			if reqErr.StatusCode != c.wantStatus || reqErr.Attempts != c.wantAttempts {
+				t.Errorf("expected status %d after %d attempts, got %d after %d",
+					c.wantStatus, c.wantAttempts, reqErr.StatusCode, reqErr.Attempts)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second}
+	server, _ := newScriptedServer(t, []scriptedResponse{
+		{status: 429, retryAfter: "2"},
+		{status: 429, retryAfter: "60"},
+		{status: 200, body: `{"name":"pikachu"}`},
+	})
+	client, delays := newRetryClient(t, server.URL, policy)
+
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	expected := []time.Duration{2 * time.Second, 5 * time.Second}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("expected delays %v, got %v", expected, *delays)
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	server, _ := newScriptedServer(t, []scriptedResponse{{status: 200}})
+	server.Close()
+	client, delays := newRetryClient(t, server.URL, RetryPolicy{MaxAttempts: 2})
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		t.Errorf("expected a RequestError, got %v", err)
+		return
+	}
+	if reqErr.Err == nil || reqErr.Attempts != 2 || len(*delays) != 1 {
+		t.Errorf("expected a network error after 2 attempts, got %+v", reqErr)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	cases := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{retry: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{retry: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{retry: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{retry: 10, min: 500 * time.Millisecond, max: time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("retry %v", c.retry), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				d := policy.backoff(c.retry)
+				if d < c.min || d > c.max {
+					t.Errorf("expected delay in [%v, %v], got %v", c.min, c.max, d)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "3", want: 3 * time.Second, ok: true},
+		{header: "-1", ok: false},
+		{header: "soon", ok: false},
+		{header: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second, ok: true},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%q", c.header), func(t *testing.T) {
+			got, ok := retryAfter(c.header, now)
+			if ok != c.ok || got != c.want {
+				t.Errorf("expected (%v, %v), got (%v, %v)", c.want, c.ok, got, ok)
+			}
+		})
+	}
+}
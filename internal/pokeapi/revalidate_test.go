@@ -0,0 +1,224 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+)
+
+// manualClock is a pokecache.Clock that only moves when told to, its
+// tickers never fire
+type manualClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *manualClock) NewTicker(time.Duration) pokecache.Ticker {
+	return stoppedTicker{}
+}
+
+type stoppedTicker struct{}
+
+func (stoppedTicker) C() <-chan time.Time { return nil }
+func (stoppedTicker) Stop()               {}
+
+func TestRevalidate(t *testing.T) {
+	const etag = `"v1"`
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	cases := []struct {
+		name       string
+		header     string // validator sent by the server
+		value      string
+		conditions string // request header that must carry it back
+	}{
+		{"etag", "ETag", etag, "If-None-Match"},
+		{"last modified", "Last-Modified", lastModified, "If-Modified-Since"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			requests, notModified := 0, 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set(c.header, c.value)
+				if r.Header.Get(c.conditions) == c.value {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fmt.Fprint(w, `{"name":"pikachu","id":25}`)
+			}))
+			defer server.Close()
+
+			clock := &manualClock{now: time.Now()}
+			cache := pokecache.NewCache(time.Minute,
+				pokecache.WithClock(clock),
+				pokecache.WithStaleRetention(time.Hour))
+			defer cache.Close()
+			client := NewClient(cache, server.URL)
+			ctx := context.Background()
+
+			if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			firstCreated := mustPeek(t, cache, server.URL+"/pokemon/pikachu/").CreatedAt
+
+			// fresh, served from the cache without asking the server
+			if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requests != 1 {
+				t.Fatalf("expected 1 request, got %d", requests)
+			}
+
+			// expired, revalidated with a conditional request
+			clock.Advance(2 * time.Minute)
+			pokemon, err := client.GetPokemon(ctx, "pikachu")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pokemon.Name != "pikachu" || pokemon.ID != 25 {
+				t.Errorf("unexpected pokemon: %+v", pokemon)
+			}
+			if requests != 2 || notModified != 1 {
+				t.Errorf("expected 2 requests with 1 not modified, got %d and %d", requests, notModified)
+			}
+			entry := mustPeek(t, cache, server.URL+"/pokemon/pikachu/")
+			if !entry.CreatedAt.After(firstCreated) {
+				t.Errorf("expected the entry to be refreshed, still created at %v", entry.CreatedAt)
+			}
+
+			// fresh again after the revalidation
+			if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requests != 2 {
+				t.Errorf("expected 2 requests, got %d", requests)
+			}
+		})
+	}
+}
+
+func mustPeek(t *testing.T, cache *pokecache.Cache, key string) pokecache.Entry {
+	t.Helper()
+	entry, ok := cache.Peek(key)
+	if !ok {
+		t.Fatalf("expected %s to be cached", key)
+	}
+	return entry
+}
+
+// newFlakyServer serves a pikachu until its status is set to an error
+func newFlakyServer(t *testing.T) (*httptest.Server, *int, *int) {
+	t.Helper()
+	requests, status := 0, http.StatusOK
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, `{"name":"pikachu","id":25}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &status
+}
+
+func TestStaleIfError(t *testing.T) {
+	server, _, status := newFlakyServer(t)
+	clock := &manualClock{now: time.Now()}
+	cache := pokecache.NewCache(time.Minute,
+		pokecache.WithClock(clock),
+		pokecache.WithStaleRetention(time.Hour))
+	defer cache.Close()
+	client := NewClient(cache, server.URL, WithRetryPolicy(NoRetry))
+	ctx := context.Background()
+
+	if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+
+	// the API is down, the expired entry is served
+	*status = http.StatusServiceUnavailable
+	pokemon, err := client.GetPokemon(ctx, "pikachu")
+	if err != nil || pokemon.Name != "pikachu" {
+		t.Errorf("expected the stale pikachu, got %+v, %v", pokemon, err)
+	}
+
+	// the API answered, the answer is not hidden
+	*status = http.StatusNotFound
+	if _, err := client.GetPokemon(ctx, "pikachu"); err == nil {
+		t.Errorf("expected the not found error")
+	}
+
+	// past the grace period there is nothing to fall back on
+	*status = http.StatusServiceUnavailable
+	clock.Advance(2 * time.Hour)
+	if _, err := client.GetPokemon(ctx, "pikachu"); err == nil {
+		t.Errorf("expected an error past the grace period")
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	server, requests, status := newFlakyServer(t)
+	clock := &manualClock{now: time.Now()}
+	cache := pokecache.NewCache(time.Minute,
+		pokecache.WithClock(clock),
+		pokecache.WithStaleRetention(time.Hour))
+	defer cache.Close()
+	client := NewClient(cache, server.URL, WithRetryPolicy(NoRetry), WithStaleWhileRevalidate())
+	ctx := context.Background()
+
+	if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+
+	// a failed background refresh keeps the stale entry
+	*status = http.StatusServiceUnavailable
+	if pokemon, err := client.GetPokemon(ctx, "pikachu"); err != nil || pokemon.Name != "pikachu" {
+		t.Fatalf("expected the stale pikachu, got %+v, %v", pokemon, err)
+	}
+	client.Wait()
+	if _, ok, _ := pokecache.NewTyped(cache, decodeJSON[Pokemon]).GetStale(server.URL + "/pokemon/pikachu/"); !ok {
+		t.Fatalf("expected the entry to stay stale")
+	}
+
+	// a successful one makes it fresh again
+	*status = http.StatusOK
+	if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.Wait()
+	if *requests != 3 {
+		t.Errorf("expected 3 requests, got %d", *requests)
+	}
+	if _, err := client.GetPokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *requests != 3 {
+		t.Errorf("expected the refreshed entry to be fresh, got %d requests", *requests)
+	}
+}
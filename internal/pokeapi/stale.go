@@ -0,0 +1,53 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+)
+
+// WithStaleWhileRevalidate serves expired responses still kept by the
+// cache right away, and refreshes them in the background for the next
+// request. Without it, they are refreshed first and only served when the
+// API can't be reached. See pokecache.WithStaleRetention.
+func WithStaleWhileRevalidate() Option {
+	return func(c *Client) {
+		c.staleWhileRevalidate = true
+	}
+}
+
+// refreshInBackground runs refresh for url in a new goroutine, unless url
+// is already being refreshed. The refresh outlives ctx, so cancelling the
+// command that served the stale response doesn't cancel it.
+func (c *Client) refreshInBackground(ctx context.Context, url string, refresh func(context.Context)) {
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+	if c.refreshing[url] {
+		return
+	}
+	c.refreshing[url] = true
+	c.refreshes.Add(1)
+
+	go func() {
+		defer c.refreshes.Done()
+		refresh(context.WithoutCancel(ctx))
+		c.refreshMutex.Lock()
+		delete(c.refreshing, url)
+		c.refreshMutex.Unlock()
+	}()
+}
+
+// Wait blocks until the responses being refreshed in the background are
+// cached. Call it before closing the cache of the client.
+func (c *Client) Wait() {
+	c.refreshes.Wait()
+}
+
+// isUnavailable reports whether err means the API could not answer, as
+// opposed to answering with an error such as 404 Not Found
+func isUnavailable(err error) bool {
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+	return reqErr.StatusCode == 0 || isRetryableStatus(reqErr.StatusCode)
+}
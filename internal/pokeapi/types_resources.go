@@ -0,0 +1,17 @@
+package pokeapi
+
+// NamedAPIResource is a reference to a resource, as listed by the list
+// endpoints
+type NamedAPIResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// NamedAPIResourceList is a single page of a list endpoint, such as
+// /pokemon or /location-area
+type NamedAPIResourceList struct {
+	Count    int                `json:"count"`
+	Next     string             `json:"next"`     // empty on the last page
+	Previous string             `json:"previous"` // empty on the first page
+	Results  []NamedAPIResource `json:"results"`
+}
@@ -0,0 +1,42 @@
+package pokecache
+
+// Alias makes alias another key for the entry of key: every method taking
+// a key, adding entries included, uses key when given alias. It is meant
+// for keys known to hold the same value, such as a resource fetched by
+// name or by id. An entry cached under alias itself is removed.
+// Aliases are kept in memory only and are not chained, an alias of an
+// alias is ignored.
+func (c *Cache) Alias(alias, key string) {
+	if alias == key {
+		return
+	}
+	c.aliasMutex.Lock()
+	if _, ok := c.aliases[key]; ok {
+		c.aliasMutex.Unlock()
+		return
+	}
+	c.aliases[alias] = key
+	c.aliasMutex.Unlock()
+
+	s := c.shardFor(alias)
+	s.mutex.Lock()
+	entry, ok := s.entries[alias]
+	if ok {
+		s.remove(entry)
+	}
+	s.mutex.Unlock()
+	if ok {
+		c.removed([]*cacheEntry{entry}, EvictDeleted)
+	}
+}
+
+// resolve returns the key alias stands for, or alias itself if it is no
+// alias
+func (c *Cache) resolve(alias string) string {
+	c.aliasMutex.RLock()
+	defer c.aliasMutex.RUnlock()
+	if key, ok := c.aliases[alias]; ok {
+		return key
+	}
+	return alias
+}
@@ -0,0 +1,36 @@
+package pokecache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlias(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute)
+	cache.Add("https://example.com/25/", []byte("outdated"))
+	cache.Add("https://example.com/pikachu/", []byte("testdata"))
+	cache.Alias("https://example.com/25/", "https://example.com/pikachu/")
+
+	val, ok := cache.Get("https://example.com/25/")
+	if !ok || string(val) != "testdata" {
+		t.Errorf("expected the aliased entry, got %q", val)
+		return
+	}
+	if stats := cache.Stats(); stats.Entries != 1 {
+		t.Errorf("expected the entry cached under the alias to be removed, got %d entries", stats.Entries)
+		return
+	}
+
+	// adding through the alias replaces the aliased entry
+	cache.Add("https://example.com/25/", []byte("updated"))
+	if val, ok := cache.Get("https://example.com/pikachu/"); !ok || string(val) != "updated" {
+		t.Errorf("expected the updated entry, got %q", val)
+		return
+	}
+
+	// aliases of aliases are ignored
+	cache.Alias("https://example.com/0x19/", "https://example.com/25/")
+	if _, ok := cache.Get("https://example.com/0x19/"); ok {
+		t.Errorf("expected to not find key")
+	}
+}
@@ -0,0 +1,115 @@
+package pokecache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	created chan struct{} // receives once for every new ticker
+}
+
+type fakeTicker struct {
+	c    chan time.Time
+	d    time.Duration
+	next time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		created: make(chan struct{}, 16),
+	}
+}
+
+// newFakeCache returns a cache using a fake clock, once its reaper is
+// waiting for ticks
+func newFakeCache(t *testing.T, interval time.Duration, opts ...Option) (*Cache, *fakeClock) {
+	t.Helper()
+	clock := newFakeClock()
+	cache := NewCache(interval, append(opts, WithClock(clock))...)
+	t.Cleanup(func() { cache.Close() })
+	<-clock.created
+	return cache, clock
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ticker := &fakeTicker{
+		c:    make(chan time.Time),
+		d:    d,
+		next: c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, ticker)
+	c.created <- struct{}{}
+	return ticker
+}
+
+// Advance moves the clock forward by d, and delivers one tick to every
+// ticker that is due. It blocks until every tick has been received.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	due := []*fakeTicker{}
+	for _, ticker := range c.tickers {
+		if !now.Before(ticker.next) {
+			ticker.next = now.Add(ticker.d)
+			due = append(due, ticker)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, ticker := range due {
+		ticker.c <- now
+	}
+}
+
+// Skip moves the clock forward by d without delivering any tick
+func (c *fakeClock) Skip(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {}
+
+func TestClose(t *testing.T) {
+	cache, clock := newFakeCache(t, time.Minute)
+	cache.Add("https://example.com", []byte("testdata"))
+
+	if err := cache.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("expected closing twice to be a no-op, got %v", err)
+		return
+	}
+
+	// the reaper is gone, but entries can still be read and expire
+	if _, ok := cache.Get("https://example.com"); !ok {
+		t.Errorf("expected to find key")
+		return
+	}
+	clock.Skip(2 * time.Minute)
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+	}
+}
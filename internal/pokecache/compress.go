@@ -0,0 +1,60 @@
+package pokecache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// WithCompression gzips values on Add and gunzips them on Get, which
+// shrinks repetitive JSON bodies several times over. The size limits and
+// the Bytes statistic count the compressed size.
+func WithCompression() Option {
+	return func(c *Cache) {
+		c.compress = true
+	}
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// compress gzips val
+func compress(val []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(val); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress gunzips val, size is the length of the result
+func decompress(val []byte, size int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(val))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// value returns the uncompressed value of an entry
+func (e *cacheEntry) value() ([]byte, error) {
+	if !e.compressed {
+		return e.val, nil
+	}
+	return decompress(e.val, e.size)
+}
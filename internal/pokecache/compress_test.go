@@ -0,0 +1,76 @@
+package pokecache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompression(t *testing.T) {
+	val := []byte(strings.Repeat(`{"front_default":"https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/25.png"},`, 100))
+	cache, _ := newFakeCache(t, time.Minute, WithCompression())
+	cache.Add("https://example.com", val)
+
+	got, ok := cache.Get("https://example.com")
+	if !ok || !bytes.Equal(got, val) {
+		t.Errorf("expected to get the uncompressed value back")
+		return
+	}
+	entry, ok := cache.Peek("https://example.com")
+	if !ok || !bytes.Equal(entry.Value, val) {
+		t.Errorf("expected to peek the uncompressed value")
+		return
+	}
+
+	stats := cache.Stats()
+	if stats.RawBytes != len(val) || stats.Bytes >= len(val)/10 {
+		t.Errorf("expected the value to shrink at least 10 times, got %+v", stats)
+		return
+	}
+	if ratio := stats.CompressionRatio(); ratio < 10 {
+		t.Errorf("expected a ratio of at least 10, got %v", ratio)
+	}
+}
+
+func TestCompressionTyped(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute, WithCompression())
+	typed := NewTyped(cache, func(raw []byte) (string, error) {
+		return string(raw), nil
+	})
+	cache.Add("https://example.com", []byte("testdata"))
+
+	val, ok, err := typed.Get("https://example.com")
+	if !ok || err != nil || val != "testdata" {
+		t.Errorf("expected testdata, got %q %v %v", val, ok, err)
+	}
+}
+
+func TestCompressionDisk(t *testing.T) {
+	dir := t.TempDir()
+	val := []byte(strings.Repeat("testdata", 100))
+	cache, err := NewDiskCache(dir, time.Hour, WithCompression())
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer cache.Close()
+	cache.Add("https://example.com", val)
+	cache.Close()
+
+	// entries keep their own format, whatever the reopened cache uses
+	reopened, err := NewDiskCache(dir, time.Hour)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer reopened.Close()
+	got, ok := reopened.Get("https://example.com")
+	if !ok || !bytes.Equal(got, val) {
+		t.Errorf("expected to get the uncompressed value back")
+		return
+	}
+	if stats := reopened.Stats(); stats.RawBytes != len(val) || stats.Bytes >= len(val) {
+		t.Errorf("expected the entry to stay compressed, got %+v", stats)
+	}
+}
@@ -0,0 +1,316 @@
+package pokecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	indexFile  = "index.json"
+	entriesDir = "entries"
+)
+
+// diskStore persists cache entries in a directory: one file per entry under
+// entries/ plus an index.json that maps every key to its file and creation
+// time. Entries are written as they are added, the index only by flush,
+// which the cache calls on every reap and on Close, so adding an entry
+// doesn't rewrite the whole index. Every entry file starts with a header
+// holding its times and format, so an entry rewritten since the index was
+// last saved is still read right. Every file is written to a temporary
+// file first and then renamed, so a crash never leaves a half written
+// entry or index behind; it loses the entries added since the last flush,
+// whose files are removed on the next load.
+type diskStore struct {
+	dir   string
+	index map[string]indexEntry
+	dirty bool // index changed since it was last saved
+	mutex sync.Mutex
+}
+
+// entryMagic starts the header of an entry file. Files without it hold
+// only the value, their times and format are in the index.
+const entryMagic = "pokecache-entry/1\n"
+
+type indexEntry struct {
+	File      string    `json:"file"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Gzip      bool      `json:"gzip,omitempty"` // the file holds the gzipped value
+	Size      int       `json:"size,omitempty"` // length of the uncompressed value
+
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// openDiskStore opens the store in dir, creating the directory if needed
+func openDiskStore(dir string) (*diskStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, entriesDir), 0o755); err != nil {
+		return nil, err
+	}
+	store := &diskStore{
+		dir:   dir,
+		index: make(map[string]indexEntry),
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.index); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// load reads every entry not expired for longer than retention at now,
+// oldest first. Older entries, and entries whose file is missing, are
+// removed from the store, as are the files no entry points to. Entries
+// stored without an expiry expire ttl after their creation.
+func (s *diskStore) load(now time.Time, ttl, retention time.Duration) []*cacheEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries := []*cacheEntry{}
+	files := make(map[string]bool)
+	for key, ie := range s.index {
+		path := filepath.Join(s.dir, entriesDir, ie.File)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			delete(s.index, key)
+			s.dirty = true
+			continue
+		}
+		// the header of the file is newer than the index, if they differ
+		header, val, err := readEntryFile(data)
+		if err != nil {
+			os.Remove(path)
+			delete(s.index, key)
+			s.dirty = true
+			continue
+		}
+		if header != nil {
+			header.File = ie.File
+			if !header.equal(ie) {
+				ie = *header
+				s.index[key] = ie
+				s.dirty = true
+			}
+		}
+		if ie.ExpiresAt.IsZero() {
+			ie.ExpiresAt = ie.CreatedAt.Add(ttl)
+		}
+		if now.After(ie.ExpiresAt.Add(retention)) {
+			os.Remove(path)
+			delete(s.index, key)
+			s.dirty = true
+			continue
+		}
+		files[ie.File] = true
+		size := ie.Size
+		if !ie.Gzip {
+			size = len(val)
+		}
+		entries = append(entries, &cacheEntry{
+			key:        key,
+			createdAt:  ie.CreatedAt,
+			expiresAt:  ie.ExpiresAt,
+			val:        val,
+			compressed: ie.Gzip,
+			size:       size,
+			validators: Validators{
+				ETag:         ie.ETag,
+				LastModified: ie.LastModified,
+			},
+		})
+	}
+	s.removeUnindexed(files)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].createdAt.Before(entries[j].createdAt)
+	})
+	return entries
+}
+
+// removeUnindexed removes the files of entries/ that are not in files,
+// such as entries written after the index was last saved or temporary
+// files left by a crash. The mutex must be held.
+func (s *diskStore) removeUnindexed(files map[string]bool) {
+	dirEntries, err := os.ReadDir(filepath.Join(s.dir, entriesDir))
+	if err != nil {
+		return
+	}
+	for _, de := range dirEntries {
+		if !de.IsDir() && !files[de.Name()] {
+			os.Remove(filepath.Join(s.dir, entriesDir, de.Name()))
+		}
+	}
+}
+
+// equal reports whether ie and other describe the same file, with the
+// same times and format
+func (ie indexEntry) equal(other indexEntry) bool {
+	return ie.File == other.File &&
+		ie.CreatedAt.Equal(other.CreatedAt) && ie.ExpiresAt.Equal(other.ExpiresAt) &&
+		ie.Gzip == other.Gzip && ie.Size == other.Size &&
+		ie.ETag == other.ETag && ie.LastModified == other.LastModified
+}
+
+// put writes an entry and adds it to the index
+func (s *diskStore) put(entry *cacheEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ie := newIndexEntry(fileName(entry.key), entry)
+	if err := writeEntryFile(filepath.Join(s.dir, entriesDir, ie.File), ie, entry.val); err != nil {
+		return err
+	}
+	s.index[entry.key] = ie
+	s.dirty = true
+	return nil
+}
+
+// touch rewrites an entry after its times changed, if it is stored
+func (s *diskStore) touch(entry *cacheEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, ok := s.index[entry.key]
+	if !ok {
+		return nil
+	}
+	ie := newIndexEntry(old.File, entry)
+	if err := writeEntryFile(filepath.Join(s.dir, entriesDir, ie.File), ie, entry.val); err != nil {
+		return err
+	}
+	s.index[entry.key] = ie
+	s.dirty = true
+	return nil
+}
+
+func newIndexEntry(file string, entry *cacheEntry) indexEntry {
+	return indexEntry{
+		File:         file,
+		CreatedAt:    entry.createdAt,
+		ExpiresAt:    entry.expiresAt,
+		Gzip:         entry.compressed,
+		Size:         entry.size,
+		ETag:         entry.validators.ETag,
+		LastModified: entry.validators.LastModified,
+	}
+}
+
+// remove deletes entries and removes them from the index
+func (s *diskStore) remove(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, key := range keys {
+		ie, ok := s.index[key]
+		if !ok {
+			continue
+		}
+		os.Remove(filepath.Join(s.dir, entriesDir, ie.File))
+		delete(s.index, key)
+		s.dirty = true
+	}
+	return nil
+}
+
+// flush saves the index, if it changed
+func (s *diskStore) flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	if err := s.writeIndex(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// writeIndex saves the index, the mutex must be held
+func (s *diskStore) writeIndex() error {
+	data, err := json.Marshal(s.index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, indexFile), data)
+}
+
+// writeEntryFile writes val to path, after a header holding ie
+func writeEntryFile(path string, ie indexEntry, val []byte) error {
+	header, err := json.Marshal(ie)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0, len(entryMagic)+len(header)+1+len(val))
+	data = append(data, entryMagic...)
+	data = append(data, header...)
+	data = append(data, '\n')
+	data = append(data, val...)
+	return writeFileAtomic(path, data)
+}
+
+// readEntryFile splits the data of an entry file into its header and its
+// value. The header is nil for files written without one.
+func readEntryFile(data []byte) (*indexEntry, []byte, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(entryMagic))
+	if !ok {
+		return nil, data, nil
+	}
+	header, val, ok := bytes.Cut(rest, []byte("\n"))
+	if !ok {
+		return nil, nil, errors.New("entry file without a value")
+	}
+	ie := &indexEntry{}
+	if err := json.Unmarshal(header, ie); err != nil {
+		return nil, nil, err
+	}
+	return ie, val, nil
+}
+
+// fileName returns the name of the file an entry is stored in. Keys are
+// URLs, so they are hashed to get a valid and fixed length file name.
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic replaces the file at path with data, readers see either
+// the old or the new content but never a partial write
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,182 @@
+package pokecache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskCachePersists(t *testing.T) {
+	const interval = time.Hour
+	dir := t.TempDir()
+
+	cache, err := NewDiskCache(dir, interval)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer cache.Close()
+	cache.Add("https://example.com", []byte("testdata"))
+	cache.Add("https://example.com/path", []byte("moretestdata"))
+
+	// the index is saved on Close, not on every Add
+	if _, err := os.Stat(filepath.Join(dir, indexFile)); err == nil {
+		t.Errorf("expected the index to not be saved yet")
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := NewDiskCache(dir, interval)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer reopened.Close()
+	val, ok := reopened.Get("https://example.com/path")
+	if !ok {
+		t.Errorf("expected to find key")
+		return
+	}
+	if string(val) != "moretestdata" {
+		t.Errorf("expected to find value")
+		return
+	}
+	before, _ := cache.Peek("https://example.com")
+	after, _ := reopened.Peek("https://example.com")
+	if !after.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("expected createdAt to be kept")
+		return
+	}
+
+	// only the index and the entries are left behind, no temporary files
+	files, err := filepath.Glob(filepath.Join(dir, "*", ".tmp-*"))
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected no temporary files, got %v", files)
+	}
+}
+
+func TestDiskCacheDropsExpiredEntries(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	cache, err := NewDiskCache(dir, time.Hour, WithClock(clock))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer cache.Close()
+	cache.AddWithTTL("https://example.com", []byte("testdata"), time.Millisecond)
+	cache.Close()
+
+	clock.Skip(5 * time.Millisecond)
+
+	reopened, err := NewDiskCache(dir, time.Hour, WithClock(clock))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer reopened.Close()
+	if _, ok := reopened.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+		return
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, entriesDir))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected expired entry file to be removed, got %v", entries)
+	}
+}
+
+func TestDiskCacheCorruptIndex(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, indexFile), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewDiskCache(dir, time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("expected a decoding error, got %v", err)
+	}
+}
+
+func TestDiskCacheOversizeReplace(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir, time.Hour, WithMaxBytes(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("old"))
+	cache.Add("https://example.com", []byte("12345678901"))
+	cache.Close()
+
+	reopened, err := NewDiskCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reopened.Close()
+	if val, ok := reopened.Get("https://example.com"); ok {
+		t.Errorf("expected the replaced value to be removed from disk, got %s", val)
+	}
+}
+
+func TestDiskCacheRemovesUnindexedFiles(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+	cache.Close()
+
+	// left behind by a crash before the index was saved
+	for _, name := range []string{fileName("https://example.com/lost"), ".tmp-123"} {
+		if err := os.WriteFile(filepath.Join(dir, entriesDir, name), []byte("lost"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewDiskCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reopened.Close()
+	if _, ok := reopened.Get("https://example.com"); !ok {
+		t.Errorf("expected to find key")
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, entriesDir))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected only the indexed entry file to be kept, got %v", entries)
+	}
+}
+
+func TestDiskCacheEntryNewerThanIndex(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	cache, err := NewDiskCache(dir, time.Minute, WithClock(clock))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("old"))
+	cache.Close()
+
+	// rewritten compressed and with a longer TTL, but the index is not
+	// saved before the next load, as after a crash
+	crashed, err := NewDiskCache(dir, time.Minute, WithClock(clock), WithCompression())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer crashed.Close()
+	val := []byte(strings.Repeat("new", 100))
+	crashed.AddWithTTL("https://example.com", val, time.Hour)
+	clock.Skip(2 * time.Minute)
+
+	reopened, err := NewDiskCache(dir, time.Minute, WithClock(clock))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reopened.Close()
+	got, ok := reopened.Get("https://example.com")
+	if !ok || string(got) != string(val) {
+		t.Errorf("expected the rewritten value, got %q, %v", got, ok)
+	}
+}
@@ -0,0 +1,99 @@
+package pokecache
+
+import "sync"
+
+// EvictReason tells why an entry left the cache
+type EvictReason int
+
+const (
+	EvictExpired EvictReason = iota // expired, removed by the reaper
+	EvictSize                       // least recently used, removed to stay within the size limits
+	EvictDeleted                    // removed by Delete, Clear or Alias
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "expired"
+	case EvictSize:
+		return "size"
+	case EvictDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// hooks are the callbacks registered with OnAdd and OnEvict
+type hooks struct {
+	mutex   sync.RWMutex
+	onAdd   []func(key string, val []byte)
+	onEvict []func(key string, val []byte, reason EvictReason)
+}
+
+// OnAdd registers fn to be called with every entry added to the cache,
+// once it is added. Entries replaced by a new value are not evicted, fn
+// is called with the new value instead.
+// Callbacks run on the goroutine that added the entry, without any lock of
+// the cache held, so they may use the cache. val must not be modified.
+func (c *Cache) OnAdd(fn func(key string, val []byte)) {
+	c.hooks.mutex.Lock()
+	defer c.hooks.mutex.Unlock()
+	c.hooks.onAdd = append(c.hooks.onAdd, fn)
+}
+
+// OnEvict registers fn to be called with every entry removed from the
+// cache, and why. Like OnAdd callbacks, it runs without any lock of the
+// cache held, on the goroutine that removed the entry: the reaper for
+// expired entries. val must not be modified.
+func (c *Cache) OnEvict(fn func(key string, val []byte, reason EvictReason)) {
+	c.hooks.mutex.Lock()
+	defer c.hooks.mutex.Unlock()
+	c.hooks.onEvict = append(c.hooks.onEvict, fn)
+}
+
+// added runs the OnAdd callbacks for entry, no lock may be held
+func (c *Cache) added(entry *cacheEntry) {
+	c.hooks.mutex.RLock()
+	onAdd := c.hooks.onAdd
+	c.hooks.mutex.RUnlock()
+	if len(onAdd) == 0 {
+		return
+	}
+	val, _ := entry.value()
+	for _, fn := range onAdd {
+		fn(entry.key, val)
+	}
+}
+
+// removed removes entries, already taken out of their shard, from the disk
+// store and runs the OnEvict callbacks for them. No lock may be held.
+func (c *Cache) removed(entries []*cacheEntry, reason EvictReason) {
+	if len(entries) == 0 {
+		return
+	}
+	if c.store != nil {
+		c.store.remove(keys(entries)...)
+	}
+
+	c.hooks.mutex.RLock()
+	onEvict := c.hooks.onEvict
+	c.hooks.mutex.RUnlock()
+	if len(onEvict) == 0 {
+		return
+	}
+	for _, entry := range entries {
+		val, _ := entry.value()
+		for _, fn := range onEvict {
+			fn(entry.key, val, reason)
+		}
+	}
+}
+
+// keys returns the keys of entries
+func keys(entries []*cacheEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
@@ -0,0 +1,76 @@
+package pokecache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	cache, clock := newFakeCache(t, time.Minute, WithMaxEntries(2))
+	events := make(chan string, 16)
+	cache.OnAdd(func(key string, val []byte) {
+		events <- fmt.Sprintf("add %s %s", key, val)
+	})
+	cache.OnEvict(func(key string, val []byte, reason EvictReason) {
+		// the cache is usable from a callback, its locks are not held
+		cache.Stats()
+		events <- fmt.Sprintf("evict %s %s %s", key, val, reason)
+	})
+	expect := func(expected ...string) {
+		t.Helper()
+		for _, e := range expected {
+			select {
+			case actual := <-events:
+				if actual != e {
+					t.Errorf("expected %q, got %q", e, actual)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected %q, got nothing", e)
+			}
+		}
+		select {
+		case actual := <-events:
+			t.Errorf("unexpected %q", actual)
+		default:
+		}
+	}
+
+	cache.Add("a", []byte("1"))
+	cache.AddWithTTL("b", []byte("2"), time.Hour)
+	expect("add a 1", "add b 2")
+
+	cache.Get("a")
+	cache.Add("c", []byte("3"))
+	expect("evict b 2 size", "add c 3")
+	cache.Add("c", []byte("4"))
+	expect("add c 4")
+
+	clock.Advance(2 * time.Minute)
+	// both expired, in no particular order
+	first, second := <-events, <-events
+	if !(first == "evict a 1 expired" && second == "evict c 4 expired") &&
+		!(first == "evict c 4 expired" && second == "evict a 1 expired") {
+		t.Errorf("expected a and c to expire, got %q and %q", first, second)
+	}
+
+	cache.Add("d", []byte("5"))
+	cache.Delete("d")
+	expect("add d 5", "evict d 5 deleted")
+	cache.Add("e", []byte("6"))
+	cache.Clear()
+	expect("add e 6", "evict e 6 deleted")
+}
+
+func TestHooksOversizeReplace(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute, WithMaxBytes(10))
+	evicted := []string{}
+	cache.OnEvict(func(key string, val []byte, reason EvictReason) {
+		evicted = append(evicted, fmt.Sprintf("%s %s %s", key, val, reason))
+	})
+	cache.Add("a", []byte("old"))
+	cache.Add("a", []byte("12345678901"))
+	if len(evicted) != 1 || evicted[0] != "a old size" {
+		t.Errorf("expected the replaced value to be evicted, got %v", evicted)
+	}
+}
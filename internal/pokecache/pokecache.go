@@ -1,72 +1,329 @@
 package pokecache
 
 import (
+	"container/list"
+	"log/slog"
 	"sync"
 	"time"
 )
 
 type Cache struct {
-	entries map[string]cacheEntry
-	mutex   sync.Mutex
+	shards     []*shard
+	numShards  int
+	maxEntries int // 0 means no limit
+	maxBytes   int // 0 means no limit
+	ttl        time.Duration
+	ttlPolicy  TTLPolicy // nil uses ttl for every entry
+	compress   bool
+	retention  time.Duration // how long expired entries are kept
+	store      *diskStore    // nil for a memory only cache
+	clock      Clock
+
+	aliases    map[string]string // alias key -> key, see Alias
+	aliasMutex sync.RWMutex
+	hooks      hooks
+
+	stop      chan struct{} // closed by Close to stop the reaper
+	done      chan struct{} // closed once the reaper stopped
+	closeOnce sync.Once
 }
 
 type cacheEntry struct {
-	createdAt time.Time
-	val       []byte
+	key        string
+	createdAt  time.Time
+	expiresAt  time.Time
+	val        []byte // gzipped when compressed is true
+	compressed bool
+	size       int // length of the uncompressed value
+	validators Validators
+	decoded    any           // value decoded from val by a Typed cache, nil until then, guarded by the shard mutex
+	elem       *list.Element // position in the lru list
+}
+
+// Option configures a Cache
+type Option func(*Cache)
+
+// WithShards splits the cache in n independently locked shards, so that
+// concurrent callers using different keys don't wait for each other.
+// The size limits are divided evenly between the shards, and the least
+// recently used entry is picked per shard rather than across the cache.
+// A cache limited to fewer entries or bytes than n has one shard per entry
+// or byte instead. The default is a single shard.
+func WithShards(n int) Option {
+	return func(c *Cache) {
+		c.numShards = n
+	}
+}
+
+// WithMaxEntries limits the number of entries in the cache.
+// When the limit is reached, the least recently used entry is evicted.
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes limits the total size of the values in the cache.
+// When the limit is reached, the least recently used entries are evicted.
+// A value larger than the limit is not cached at all.
+func WithMaxBytes(n int) Option {
+	return func(c *Cache) {
+		c.maxBytes = n
+	}
+}
+
+// TTLPolicy picks how long an entry stays in the cache from its key.
+// Returning 0 or less uses the interval of the cache.
+type TTLPolicy func(key string) time.Duration
+
+// WithTTLPolicy sets the TTL of entries added with Add
+func WithTTLPolicy(policy TTLPolicy) Option {
+	return func(c *Cache) {
+		c.ttlPolicy = policy
+	}
 }
 
 // constructor for Cache
-func NewCache(interval time.Duration) *Cache {
-	cache := &Cache{
-		entries: make(map[string]cacheEntry),
+// interval: TTL of the entries, unless a TTLPolicy or AddWithTTL says
+// otherwise, and how often expired entries are removed
+// Close must be called once the cache is no longer used.
+func NewCache(interval time.Duration, opts ...Option) *Cache {
+	cache := newCache(interval, opts)
+
+	go cache.reapLoop(interval)
+
+	return cache
+}
+
+// constructor for a Cache persisted in dir, so entries survive restarts.
+// Entries already stored in dir are loaded, except the expired ones.
+// Failing to write an entry to disk is not an error, the entry is still
+// cached in memory. Close must be called once the cache is no longer used.
+func NewDiskCache(dir string, interval time.Duration, opts ...Option) (*Cache, error) {
+	store, err := openDiskStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	cache := newCache(interval, opts)
+	cache.store = store
+	for _, entry := range store.load(cache.clock.Now(), interval, cache.retention) {
+		cache.shardFor(entry.key).insert(entry)
+	}
+	for _, s := range cache.shards {
+		store.remove(keys(s.evict())...)
 	}
 
 	go cache.reapLoop(interval)
 
+	return cache, nil
+}
+
+func newCache(interval time.Duration, opts []Option) *Cache {
+	cache := &Cache{
+		numShards: 1,
+		ttl:       interval,
+		clock:     realClock{},
+		aliases:   make(map[string]string),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(cache)
+	}
+	// every shard needs a share of at least 1, a share of 0 means no limit
+	for _, limit := range []int{cache.maxEntries, cache.maxBytes} {
+		if limit > 0 && cache.numShards > limit {
+			cache.numShards = limit
+		}
+	}
+	if cache.numShards < 1 {
+		cache.numShards = 1
+	}
+	for i := 0; i < cache.numShards; i++ {
+		cache.shards = append(cache.shards, newShard(
+			divideLimit(cache.maxEntries, cache.numShards, i),
+			divideLimit(cache.maxBytes, cache.numShards, i),
+		))
+	}
 	return cache
 }
 
-// Adds a new entry to the cache
+// divideLimit returns the share of limit of shard i out of n. The first
+// limit%n shards get one more than the others, so the shares add up to
+// limit.
+func divideLimit(limit, n, i int) int {
+	if limit <= 0 {
+		return 0
+	}
+	share := limit / n
+	if i < limit%n {
+		share++
+	}
+	return share
+}
+
+// Adds a new entry to the cache, expiring after the TTL picked by the
+// TTLPolicy of the cache
 // key: url to API call
 // val: value of the API call
 func (c *Cache) Add(key string, val []byte) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.add(key, val, c.policyTTL(key), nil, Validators{})
+}
+
+// AddWithTTL adds a new entry to the cache that expires after ttl.
+// A ttl of 0 or less uses the interval of the cache.
+func (c *Cache) AddWithTTL(key string, val []byte, ttl time.Duration) {
+	c.add(key, val, ttl, nil, Validators{})
+}
+
+// policyTTL returns the TTL the TTLPolicy picks for key, 0 without policy
+func (c *Cache) policyTTL(key string) time.Duration {
+	if c.ttlPolicy == nil {
+		return 0
+	}
+	return c.ttlPolicy(key)
+}
+
+// add adds a new entry, with the value already decoded from val if any
+func (c *Cache) add(key string, val []byte, ttl time.Duration, decoded any, validators Validators) {
+	key = c.resolve(key)
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	now := c.clock.Now()
+	entry := c.newEntry(key, val, now, now.Add(ttl), validators)
+	entry.decoded = decoded
+	c.put(entry, nil)
+}
+
+// newEntry builds an entry, compressing val when compression is enabled
+func (c *Cache) newEntry(key string, val []byte, createdAt, expiresAt time.Time, validators Validators) *cacheEntry {
+	entry := &cacheEntry{
+		key:        key,
+		createdAt:  createdAt,
+		expiresAt:  expiresAt,
+		val:        val,
+		size:       len(val),
+		validators: validators,
+	}
+	if c.compress {
+		// an entry that fails to compress is kept uncompressed
+		if compressed, err := compress(val); err == nil {
+			entry.val, entry.compressed = compressed, true
+		}
+	}
+	return entry
+}
 
-	entry := cacheEntry{
-		createdAt: time.Now(),
-		val:       val,
+// put caches entry and writes it through to the disk store. When an entry
+// with the same key is cached, entry only replaces it if replace, called
+// with the cached entry under the shard lock, returns true; a nil replace
+// always replaces. false if entry was not kept.
+func (c *Cache) put(entry *cacheEntry, replace func(old *cacheEntry) bool) bool {
+	s := c.shardFor(entry.key)
+	s.mutex.Lock()
+	if old, ok := s.entries[entry.key]; ok && replace != nil && !replace(old) {
+		s.mutex.Unlock()
+		return false
 	}
-	c.entries[key] = entry
+	// a value too large for the cache is rejected before it can push
+	// out the other entries
+	kept := s.fits(entry)
+	var evicted []*cacheEntry
+	if kept {
+		s.insert(entry)
+		evicted = s.evict()
+	} else if old, ok := s.entries[entry.key]; ok {
+		// the value it replaces is outdated, it is evicted from memory,
+		// from the disk store and reported to OnEvict
+		s.remove(old)
+		s.stats.Evictions++
+		evicted = append(evicted, old)
+	}
+	s.mutex.Unlock()
+
+	if kept && c.store != nil {
+		c.store.put(entry)
+	}
+	c.removed(evicted, EvictSize)
+	if kept {
+		c.added(entry)
+	}
+	return kept
 }
 
-// .Get() gets an entry from the cache.
-// false no entry, true entry exists
+// .Get() gets an entry from the cache and marks it as recently used.
+// false no entry or the entry expired, true entry exists
 func (c *Cache) Get(key string) ([]byte, bool) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	entry, ok := c.entries[key]
+	entry, ok := c.get(key)
 	if !ok {
 		return nil, false
 	}
+	val, err := entry.value()
+	if err != nil {
+		// a corrupt entry is as good as no entry
+		c.Delete(key)
+		return nil, false
+	}
+	return val, true
+}
+
+// get finds a live entry, counts the hit or miss and marks the entry as
+// recently used
+func (c *Cache) get(key string) (*cacheEntry, bool) {
+	key = c.resolve(key)
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.get(key, c.clock.Now())
+}
 
-	return entry.val, true
+// expired reports whether the entry is past its expiry at now
+func (e *cacheEntry) expired(now time.Time) bool {
+	return now.After(e.expiresAt)
+}
+
+// Close stops removing expired entries and saves the index of a disk
+// cache. The entries stay readable. Calling Close more than once is a no-op.
+func (c *Cache) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+		if c.store != nil {
+			err = c.store.flush()
+		}
+	})
+	return err
 }
 
 func (c *Cache) reapLoop(interval time.Duration) {
-	ticker := time.NewTicker(interval)
+	defer close(c.done)
+	ticker := c.clock.NewTicker(interval)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
-			cutoff := time.Now().Add(interval * -1)
-			c.mutex.Lock()
-			for key, val := range c.entries {
-				if val.createdAt.Before(cutoff) {
-					delete(c.entries, key)
-				}
-			}
-			c.mutex.Unlock()
+		case <-ticker.C():
+			c.reap()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// reap removes every expired entry and saves the index of a disk cache.
+// Shards are reaped one after the other, so only one shard is locked at
+// any time and callers using the other shards carry on meanwhile.
+func (c *Cache) reap() {
+	now := c.clock.Now()
+	for _, s := range c.shards {
+		c.removed(s.reap(now, c.retention), EvictExpired)
+	}
+	if c.store != nil {
+		// the cache keeps working without its index, it is saved again
+		// on the next reap
+		if err := c.store.flush(); err != nil {
+			slog.Warn("saving the cache index failed", "dir", c.store.dir, "err", err)
 		}
 	}
 }
@@ -2,6 +2,7 @@ package pokecache
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -25,6 +26,7 @@ func TestAddGet(t *testing.T) {
 	for i, c := range cases {
 		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
 			cache := NewCache(interval)
+			defer cache.Close()
 			cache.Add(c.key, c.val)
 			val, ok := cache.Get(c.key)
 			if !ok {
@@ -42,7 +44,7 @@ func TestAddGet(t *testing.T) {
 func TestReapLoop(t *testing.T) {
 	const baseTime = 5 * time.Millisecond
 	const waitTime = baseTime + 5*time.Millisecond
-	cache := NewCache(baseTime)
+	cache, clock := newFakeCache(t, baseTime)
 	cache.Add("https://example.com", []byte("testdata"))
 
 	_, ok := cache.Get("https://example.com")
@@ -51,11 +53,167 @@ func TestReapLoop(t *testing.T) {
 		return
 	}
 
-	time.Sleep(waitTime)
+	clock.Advance(waitTime)
+	cache.Close() // waits for the reaper to finish
 
 	_, ok = cache.Get("https://example.com")
 	if ok {
 		t.Errorf("expected to not find key")
 		return
 	}
+	if cache.Stats().Entries != 0 {
+		t.Errorf("expected the reaper to remove the entry")
+		return
+	}
+}
+
+func TestMaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache(time.Minute, WithMaxEntries(2))
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+	// a becomes the most recently used entry
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("expected to find key a")
+		return
+	}
+	cache.Add("c", []byte("3"))
+
+	cases := []struct {
+		key  string
+		want bool
+	}{
+		{key: "a", want: true},
+		{key: "b", want: false},
+		{key: "c", want: true},
+	}
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			if _, ok := cache.Get(c.key); ok != c.want {
+				t.Errorf("expected found to be %v", c.want)
+			}
+		})
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	cache := NewCache(time.Minute, WithMaxBytes(10))
+	defer cache.Close()
+	cache.Add("a", []byte("1234"))
+	cache.Add("b", []byte("1234"))
+	cache.Add("c", []byte("1234"))
+
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("expected a to be evicted")
+		return
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Errorf("expected to find key c")
+		return
+	}
+	if bytes := cache.Stats().Bytes; bytes != 8 {
+		t.Errorf("expected 8 bytes cached, got %d", bytes)
+		return
+	}
+
+	// replacing an entry only counts its new size
+	cache.Add("c", []byte("12"))
+	if _, ok := cache.Get("b"); !ok || cache.Stats().Bytes != 6 {
+		t.Errorf("expected b to be kept with 6 bytes cached, got %d", cache.Stats().Bytes)
+		return
+	}
+
+	// a value larger than the limit is not cached
+	cache.Add("huge", []byte("12345678901"))
+	if _, ok := cache.Get("huge"); ok {
+		t.Errorf("expected huge value to not be cached")
+	}
+	// nor does it evict the other entries
+	for _, key := range []string{"b", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected to still find key %s", key)
+		}
+	}
+	if evictions := cache.Stats().Evictions; evictions != 1 {
+		t.Errorf("expected only a to be evicted, got %d evictions", evictions)
+	}
+}
+
+func TestReapLoopFreesCapacity(t *testing.T) {
+	const baseTime = 20 * time.Millisecond
+	cache, clock := newFakeCache(t, baseTime, WithMaxEntries(2), WithMaxBytes(2))
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+
+	clock.Advance(2 * baseTime)
+	cache.Close() // waits for the reaper to finish
+
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 || cache.shards[0].lru.Len() != 0 {
+		t.Errorf("expected the reaper to empty the lru list, got %d entries and %d bytes", stats.Entries, stats.Bytes)
+	}
+
+	// the reaped entries don't count against the limits anymore
+	cache.Add("c", []byte("3"))
+	cache.Add("d", []byte("4"))
+	for _, key := range []string{"c", "d"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected to find key %s", key)
+		}
+	}
+}
+
+func TestAddWithTTL(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	cache, clock := newFakeCache(t, interval)
+	cache.AddWithTTL("short", []byte("testdata"), time.Millisecond)
+	cache.AddWithTTL("long", []byte("testdata"), time.Hour)
+	cache.Add("default", []byte("testdata"))
+
+	clock.Advance(5 * time.Millisecond)
+
+	// expired entries are missed even before the reaper removes them
+	if _, ok := cache.Get("short"); ok {
+		t.Errorf("expected to not find short")
+		return
+	}
+	if _, ok := cache.Get("default"); !ok {
+		t.Errorf("expected to find default")
+		return
+	}
+
+	clock.Advance(interval)
+	cache.Close() // waits for the reaper to finish
+
+	if _, ok := cache.Peek("long"); !ok || cache.Stats().Entries != 1 {
+		t.Errorf("expected the reaper to keep only long, got %d entries", cache.Stats().Entries)
+	}
+}
+
+func TestTTLPolicy(t *testing.T) {
+	policy := func(key string) time.Duration {
+		if strings.HasPrefix(key, "/pokemon/") {
+			return time.Hour
+		}
+		return 0
+	}
+	cache := NewCache(time.Minute, WithTTLPolicy(policy))
+	defer cache.Close()
+	cases := []struct {
+		key  string
+		want time.Duration
+	}{
+		{key: "/pokemon/pikachu", want: time.Hour},
+		// 0 uses the interval of the cache
+		{key: "/location-area/", want: time.Minute},
+	}
+
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			cache.Add(c.key, []byte("testdata"))
+			entry, _ := cache.Peek(c.key)
+			if ttl := entry.ExpiresAt.Sub(entry.CreatedAt); ttl != c.want {
+				t.Errorf("expected %v, got %v", c.want, ttl)
+			}
+		})
+	}
 }
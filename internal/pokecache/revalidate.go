@@ -0,0 +1,90 @@
+package pokecache
+
+import "time"
+
+// Validators are the response headers used to ask the server whether a
+// cached response changed, instead of downloading it again
+type Validators struct {
+	ETag         string // sent back as If-None-Match
+	LastModified string // sent back as If-Modified-Since
+}
+
+// IsZero reports whether there is nothing to revalidate with
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// WithStaleRetention keeps expired entries for d after their expiry, as a
+// grace period. Get misses them like any expired entry, but Peek and
+// Typed.GetStale still find them, so they can be revalidated and made
+// fresh again with Refresh, or served when the server can't be reached.
+func WithStaleRetention(d time.Duration) Option {
+	return func(c *Cache) {
+		c.retention = d
+	}
+}
+
+// AddWithValidators adds a new entry along with the validators of the
+// response it came from
+func (c *Cache) AddWithValidators(key string, val []byte, validators Validators) {
+	c.add(key, val, c.policyTTL(key), nil, validators)
+}
+
+// Refresh makes an entry fresh again, as if it was just added, keeping its
+// value. It is used once the server confirmed the value did not change.
+// false if there was no such entry.
+func (c *Cache) Refresh(key string) bool {
+	key = c.resolve(key)
+	ttl := c.policyTTL(key)
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	now := c.clock.Now()
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	old, ok := s.entries[key]
+	if !ok {
+		s.mutex.Unlock()
+		return false
+	}
+	// entries are never modified once added, they are replaced instead
+	entry := *old
+	entry.createdAt = now
+	entry.expiresAt = now.Add(ttl)
+	s.insert(&entry)
+	s.mutex.Unlock()
+
+	if c.store != nil {
+		c.store.touch(&entry)
+	}
+	return true
+}
+
+// getStale finds an expired entry still within the retention
+func (c *Cache) getStale(key string) (*cacheEntry, bool) {
+	key = c.resolve(key)
+	now := c.clock.Now()
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || !entry.expired(now) || entry.expired(now.Add(-c.retention)) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Validators returns the validators of an entry, expired or not.
+// false if there is no such entry or it has no validators.
+func (c *Cache) Validators(key string) (Validators, bool) {
+	key = c.resolve(key)
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || entry.validators.IsZero() {
+		return Validators{}, false
+	}
+	return entry.validators, true
+}
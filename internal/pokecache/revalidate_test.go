@@ -0,0 +1,107 @@
+package pokecache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStaleRetention(t *testing.T) {
+	cache, clock := newFakeCache(t, time.Minute, WithStaleRetention(time.Hour))
+	validators := Validators{ETag: `"abc"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"}
+	cache.AddWithValidators("https://example.com", []byte("testdata"), validators)
+
+	clock.Advance(2 * time.Minute)
+
+	// expired entries are missed, but kept for revalidation
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+		return
+	}
+	entry, ok := cache.Peek("https://example.com")
+	if !ok || entry.Validators != validators {
+		t.Errorf("expected to peek the expired entry with its validators, got %+v", entry)
+		return
+	}
+
+	if !cache.Refresh("https://example.com") {
+		t.Errorf("expected to refresh the entry")
+		return
+	}
+	val, ok := cache.Get("https://example.com")
+	if !ok || string(val) != "testdata" {
+		t.Errorf("expected the refreshed entry to be fresh")
+		return
+	}
+	if cache.Refresh("https://example.com/missing") {
+		t.Errorf("expected to not refresh a missing entry")
+		return
+	}
+
+	// once the retention is over the reaper removes the entry
+	clock.Advance(2 * time.Hour)
+	cache.Close() // waits for the reaper to finish
+	if _, ok := cache.Peek("https://example.com"); ok {
+		t.Errorf("expected the reaper to remove the entry")
+	}
+}
+
+func TestGetStale(t *testing.T) {
+	cache, clock := newFakeCache(t, time.Minute, WithStaleRetention(time.Hour))
+	typed := NewTyped(cache, func(raw []byte) (int, error) {
+		return strconv.Atoi(string(raw))
+	})
+	typed.Add("https://example.com", []byte("42"), 42)
+
+	// fresh entries are not stale
+	if _, ok, _ := typed.GetStale("https://example.com"); ok {
+		t.Errorf("expected a fresh entry to not be stale")
+		return
+	}
+
+	clock.Skip(2 * time.Minute)
+	if _, ok, _ := typed.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+		return
+	}
+	val, ok, err := typed.GetStale("https://example.com")
+	if !ok || err != nil || val != 42 {
+		t.Errorf("expected the stale value 42, got %v %v %v", val, ok, err)
+		return
+	}
+
+	// past the retention, even if not reaped yet
+	clock.Skip(2 * time.Hour)
+	if _, ok, _ := typed.GetStale("https://example.com"); ok {
+		t.Errorf("expected to not serve an entry past the retention")
+	}
+}
+
+func TestRefreshDisk(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	cache, err := NewDiskCache(dir, time.Minute, WithClock(clock), WithStaleRetention(time.Hour))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer cache.Close()
+	cache.AddWithValidators("https://example.com", []byte("testdata"), Validators{ETag: `"abc"`})
+	clock.Skip(2 * time.Minute)
+	cache.Refresh("https://example.com")
+	cache.Close()
+
+	reopened, err := NewDiskCache(dir, time.Minute, WithClock(clock))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	defer reopened.Close()
+	if _, ok := reopened.Get("https://example.com"); !ok {
+		t.Errorf("expected the refreshed entry to be fresh after reopening")
+		return
+	}
+	if entry, _ := reopened.Peek("https://example.com"); entry.ETag != `"abc"` {
+		t.Errorf("expected the etag to be kept, got %q", entry.ETag)
+	}
+}
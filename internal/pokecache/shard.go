@@ -0,0 +1,109 @@
+package pokecache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// shard is an independently locked part of a Cache. Every key belongs to
+// exactly one shard, so operations on keys of different shards never wait
+// for each other.
+type shard struct {
+	mutex      sync.Mutex
+	entries    map[string]*cacheEntry
+	lru        *list.List // most recently used entry at the front
+	bytes      int        // total size of the cached values, as stored
+	rawBytes   int        // total size of the cached values, uncompressed
+	maxEntries int        // share of the limit of the cache, 0 means no limit
+	maxBytes   int        // share of the limit of the cache, 0 means no limit
+	stats      Stats      // Entries and Bytes are filled in by Cache.Stats()
+}
+
+func newShard(maxEntries, maxBytes int) *shard {
+	return &shard{
+		entries:    make(map[string]*cacheEntry),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+// shardFor returns the shard key belongs to
+func (c *Cache) shardFor(key string) *shard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+// get finds a live entry, counts the hit or miss and marks the entry as
+// recently used, the mutex must be held
+func (s *shard) get(key string, now time.Time) (*cacheEntry, bool) {
+	entry, ok := s.entries[key]
+	if !ok || entry.expired(now) {
+		s.stats.Misses++
+		return nil, false
+	}
+	s.stats.Hits++
+	s.lru.MoveToFront(entry.elem)
+	return entry, true
+}
+
+// insert adds or replaces an entry as the most recently used one,
+// the mutex must be held
+func (s *shard) insert(entry *cacheEntry) {
+	if old, ok := s.entries[entry.key]; ok {
+		s.remove(old)
+	}
+	entry.elem = s.lru.PushFront(entry)
+	s.entries[entry.key] = entry
+	s.bytes += len(entry.val)
+	s.rawBytes += entry.size
+}
+
+// fits reports whether entry alone is within the size limit of the shard
+func (s *shard) fits(entry *cacheEntry) bool {
+	return s.maxBytes <= 0 || len(entry.val) <= s.maxBytes
+}
+
+// remove deletes an entry, the mutex must be held
+func (s *shard) remove(entry *cacheEntry) {
+	s.lru.Remove(entry.elem)
+	delete(s.entries, entry.key)
+	s.bytes -= len(entry.val)
+	s.rawBytes -= entry.size
+}
+
+// evict removes the least recently used entries until the shard is within
+// its limits, and returns them. The mutex must be held.
+func (s *shard) evict() []*cacheEntry {
+	evicted := []*cacheEntry{}
+	for s.lru.Len() > 0 &&
+		((s.maxEntries > 0 && s.lru.Len() > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes)) {
+		entry := s.lru.Back().Value.(*cacheEntry)
+		s.remove(entry)
+		s.stats.Evictions++
+		evicted = append(evicted, entry)
+	}
+	return evicted
+}
+
+// reap removes the entries expired for longer than retention at now and
+// returns them
+func (s *shard) reap(now time.Time, retention time.Duration) []*cacheEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	expired := []*cacheEntry{}
+	for _, entry := range s.entries {
+		if entry.expired(now.Add(-retention)) {
+			s.remove(entry)
+			s.stats.Expirations++
+			expired = append(expired, entry)
+		}
+	}
+	return expired
+}
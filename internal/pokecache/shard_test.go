@@ -0,0 +1,155 @@
+package pokecache
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShards(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute, WithShards(8), WithMaxEntries(80))
+	for i := 0; i < 1000; i++ {
+		cache.Add(fmt.Sprintf("https://pokeapi.co/api/v2/pokemon/%d", i), []byte("testdata"))
+	}
+
+	used := 0
+	for _, s := range cache.shards {
+		if len(s.entries) > 10 {
+			t.Errorf("expected at most 10 entries per shard, got %d", len(s.entries))
+		}
+		if len(s.entries) > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Errorf("expected keys to be spread over the shards, got %d used", used)
+	}
+	stats := cache.Stats()
+	if stats.Entries > 80 || stats.Entries+stats.Evictions != 1000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	// the most recent key always survives in its shard
+	if _, ok := cache.Get("https://pokeapi.co/api/v2/pokemon/999"); !ok {
+		t.Errorf("expected to find the last key")
+	}
+}
+
+func TestShardLimitsAddUp(t *testing.T) {
+	cases := []struct {
+		shards, maxEntries, maxBytes int
+		expectedShards               int
+	}{
+		{shards: 4, maxEntries: 5, expectedShards: 4},
+		{shards: 4, maxBytes: 50, expectedShards: 4},
+		{shards: 8, maxEntries: 3, expectedShards: 3},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%+v", c), func(t *testing.T) {
+			cache, _ := newFakeCache(t, time.Minute,
+				WithShards(c.shards), WithMaxEntries(c.maxEntries), WithMaxBytes(c.maxBytes))
+			if len(cache.shards) != c.expectedShards {
+				t.Errorf("expected %d shards, got %d", c.expectedShards, len(cache.shards))
+			}
+			entries, bytes := 0, 0
+			for _, s := range cache.shards {
+				entries += s.maxEntries
+				bytes += s.maxBytes
+			}
+			if entries != c.maxEntries || bytes != c.maxBytes {
+				t.Errorf("expected the shard limits to add up to %d entries and %d bytes, got %d and %d",
+					c.maxEntries, c.maxBytes, entries, bytes)
+			}
+
+			for i := 0; i < 100; i++ {
+				cache.Add(strconv.Itoa(i), []byte("1"))
+			}
+			stats := cache.Stats()
+			if c.maxEntries > 0 && stats.Entries > c.maxEntries {
+				t.Errorf("expected at most %d entries, got %d", c.maxEntries, stats.Entries)
+			}
+			if c.maxBytes > 0 && stats.Bytes > c.maxBytes {
+				t.Errorf("expected at most %d bytes, got %d", c.maxBytes, stats.Bytes)
+			}
+		})
+	}
+}
+
+// TestConcurrentAccess is meant to be run with the race detector:
+// go test -race ./internal/pokecache
+func TestConcurrentAccess(t *testing.T) {
+	for _, shards := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d shards", shards), func(t *testing.T) {
+			cache, clock := newFakeCache(t, time.Second, WithShards(shards), WithMaxEntries(50))
+			typed := NewTyped(cache, func(raw []byte) (int, error) {
+				return strconv.Atoi(string(raw))
+			})
+
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 500; i++ {
+						key := strconv.Itoa((g*31 + i) % 100)
+						switch i % 5 {
+						case 0:
+							cache.Add(key, []byte(key))
+						case 1:
+							typed.Get(key)
+						case 2:
+							cache.Delete(key)
+						case 3:
+							cache.Stats()
+							cache.Peek(key)
+						default:
+							cache.Get(key)
+						}
+					}
+				}(g)
+			}
+			// the reaper runs while the goroutines use the cache
+			for i := 0; i < 10; i++ {
+				clock.Advance(time.Second)
+			}
+			wg.Wait()
+
+			stats := cache.Stats()
+			if stats.Entries > 50 || stats.Entries < 0 || stats.Bytes < 0 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+		})
+	}
+}
+
+// BenchmarkParallel compares a single shard, which is how the cache was
+// locked before sharding, with several shards, while the reaper keeps
+// scanning the entries
+func BenchmarkParallel(b *testing.B) {
+	const keys = 4096
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("%d shards", shards), func(b *testing.B) {
+			cache := NewCache(time.Millisecond, WithShards(shards))
+			defer cache.Close()
+			val := make([]byte, 512)
+			for i := 0; i < keys; i++ {
+				cache.AddWithTTL(strconv.Itoa(i), val, time.Hour)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := strconv.Itoa(i % keys)
+					if i%10 == 0 {
+						cache.AddWithTTL(key, val, time.Hour)
+					} else {
+						cache.Get(key)
+					}
+					i += 7
+				}
+			})
+		})
+	}
+}
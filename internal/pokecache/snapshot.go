@@ -0,0 +1,141 @@
+package pokecache
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot format written by Export
+const snapshotVersion = 1
+
+// snapshot is the archive written by Export: gzipped JSON holding every
+// entry with its value uncompressed, so it can be imported by any cache
+// whatever its compression setting
+type snapshot struct {
+	Version int             `json:"version"`
+	Entries []snapshotEntry `json:"entries"`
+}
+
+type snapshotEntry struct {
+	Key          string    `json:"key"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Value        []byte    `json:"value"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+// ConflictPolicy decides what Import does with an entry whose key is
+// already cached
+type ConflictPolicy int
+
+const (
+	KeepNewest ConflictPolicy = iota // keep the entry created last
+	Overwrite                        // always replace the cached entry
+	Skip                             // always keep the cached entry
+)
+
+// ParseConflictPolicy parses "newest", "overwrite" or "skip"
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch s {
+	case "newest":
+		return KeepNewest, nil
+	case "overwrite":
+		return Overwrite, nil
+	case "skip":
+		return Skip, nil
+	}
+	return 0, fmt.Errorf("unknown conflict policy %q, expected newest, overwrite or skip", s)
+}
+
+// replace reports whether an imported entry replaces the cached one
+func (p ConflictPolicy) replace(cached, imported *cacheEntry) bool {
+	switch p {
+	case Overwrite:
+		return true
+	case Skip:
+		return false
+	}
+	return imported.createdAt.After(cached.createdAt)
+}
+
+// ImportResult counts what Import did with the entries of a snapshot
+type ImportResult struct {
+	Imported int // entries added to the cache
+	Skipped  int // entries kept out by the conflict policy or the size limits
+	Expired  int // entries expired for longer than the stale retention
+}
+
+// Export writes every entry of the cache to w as a single archive that
+// Import reads back, in this cache or another one. Entries keep their
+// creation and expiry times and their validators.
+func (c *Cache) Export(w io.Writer) (int, error) {
+	entries := c.Entries()
+	snap := snapshot{
+		Version: snapshotVersion,
+		Entries: make([]snapshotEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		snap.Entries = append(snap.Entries, snapshotEntry{
+			Key:          entry.Key,
+			CreatedAt:    entry.CreatedAt,
+			ExpiresAt:    entry.ExpiresAt,
+			Value:        entry.Value,
+			ETag:         entry.ETag,
+			LastModified: entry.LastModified,
+		})
+	}
+
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return len(snap.Entries), nil
+}
+
+// Import adds the entries of an archive written by Export. Entries whose
+// key is already cached are resolved with policy. Entries expired for
+// longer than the stale retention of the cache are left out, and the size
+// limits of the cache apply as for any other entry.
+func (c *Cache) Import(r io.Reader, policy ConflictPolicy) (ImportResult, error) {
+	result := ImportResult{}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return result, fmt.Errorf("reading cache snapshot: %w", err)
+	}
+	defer zr.Close()
+	snap := snapshot{}
+	if err := json.NewDecoder(zr).Decode(&snap); err != nil {
+		return result, fmt.Errorf("reading cache snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return result, fmt.Errorf("unsupported cache snapshot version %d", snap.Version)
+	}
+
+	now := c.clock.Now()
+	for _, se := range snap.Entries {
+		entry := c.newEntry(se.Key, se.Value, se.CreatedAt, se.ExpiresAt, Validators{
+			ETag:         se.ETag,
+			LastModified: se.LastModified,
+		})
+		if entry.expired(now.Add(-c.retention)) {
+			result.Expired++
+			continue
+		}
+		replace := func(cached *cacheEntry) bool {
+			return policy.replace(cached, entry)
+		}
+		if c.put(entry, replace) {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
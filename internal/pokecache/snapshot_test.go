@@ -0,0 +1,91 @@
+package pokecache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImport(t *testing.T) {
+	src, clock := newFakeCache(t, time.Minute, WithCompression())
+	src.AddWithValidators("https://example.com/a", []byte("a-exported"), Validators{ETag: `"a"`})
+	clock.Skip(time.Second)
+	src.Add("https://example.com/b", []byte("b-exported"))
+
+	archive := bytes.Buffer{}
+	n, err := src.Export(&archive)
+	if err != nil || n != 2 {
+		t.Fatalf("expected to export 2 entries, got %d, %v", n, err)
+	}
+
+	cases := []struct {
+		policy ConflictPolicy
+		a, b   string // values after the import
+	}{
+		// "a" was cached before the export, "b" after
+		{KeepNewest, "a-exported", "b-cached"},
+		{Overwrite, "a-exported", "b-exported"},
+		{Skip, "a-cached", "b-cached"},
+	}
+	for _, c := range cases {
+		dst, dstClock := newFakeCache(t, time.Hour)
+		dstClock.Skip(-time.Minute)
+		dst.Add("https://example.com/a", []byte("a-cached"))
+		dstClock.Skip(2 * time.Minute)
+		dst.Add("https://example.com/b", []byte("b-cached"))
+
+		result, err := dst.Import(bytes.NewReader(archive.Bytes()), c.policy)
+		if err != nil {
+			t.Errorf("policy %d: unexpected error: %v", c.policy, err)
+			continue
+		}
+		imported := 0
+		for key, want := range map[string]string{"https://example.com/a": c.a, "https://example.com/b": c.b} {
+			entry, ok := dst.Peek(key)
+			if !ok || string(entry.Value) != want {
+				t.Errorf("policy %d: expected %s for %s, got %q", c.policy, want, key, entry.Value)
+			}
+			if strings.HasSuffix(want, "exported") {
+				imported++
+			}
+		}
+		if result.Imported != imported || result.Skipped != 2-imported {
+			t.Errorf("policy %d: expected %d imported, got %+v", c.policy, imported, result)
+		}
+	}
+
+	// times and validators survive the round trip
+	dst, _ := newFakeCache(t, time.Minute)
+	if _, err := dst.Import(bytes.NewReader(archive.Bytes()), KeepNewest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := src.Peek("https://example.com/a")
+	got, _ := dst.Peek("https://example.com/a")
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.ExpiresAt.Equal(want.ExpiresAt) || got.Validators != want.Validators {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestImportExpired(t *testing.T) {
+	src, _ := newFakeCache(t, time.Minute)
+	src.Add("https://example.com", []byte("testdata"))
+	archive := bytes.Buffer{}
+	if _, err := src.Export(&archive); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dst, clock := newFakeCache(t, time.Minute, WithStaleRetention(time.Hour))
+	clock.Skip(2 * time.Hour)
+	result, err := dst.Import(&archive, KeepNewest)
+	if err != nil || result.Expired != 1 || result.Imported != 0 {
+		t.Errorf("expected the entry to be expired, got %+v, %v", result, err)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute)
+	if _, err := cache.Import(bytes.NewReader([]byte("not an archive")), KeepNewest); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,142 @@
+package pokecache
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats counts what happened in a cache since it was created
+type Stats struct {
+	Hits        int // Get found a live entry
+	Misses      int // Get found no entry or an expired one
+	Evictions   int // entries removed to stay within the size limits
+	Expirations int // expired entries removed by the reaper
+	Entries     int // entries currently cached
+	Bytes       int // total size of the values currently cached, compressed if enabled
+	RawBytes    int // total size of the values currently cached, uncompressed
+}
+
+// CompressionRatio returns how many times smaller the values are in the
+// cache than uncompressed, 1 without compression or entries
+func (s Stats) CompressionRatio() float64 {
+	if s.Bytes == 0 {
+		return 1
+	}
+	return float64(s.RawBytes) / float64(s.Bytes)
+}
+
+// Entry is a copy of a cached entry, for inspection
+type Entry struct {
+	Key       string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Value     []byte
+	Validators
+}
+
+// Stats returns the counters of the cache
+func (c *Cache) Stats() Stats {
+	stats := Stats{}
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		stats.Hits += s.stats.Hits
+		stats.Misses += s.stats.Misses
+		stats.Evictions += s.stats.Evictions
+		stats.Expirations += s.stats.Expirations
+		stats.Entries += len(s.entries)
+		stats.Bytes += s.bytes
+		stats.RawBytes += s.rawBytes
+		s.mutex.Unlock()
+	}
+	return stats
+}
+
+// Entries returns every entry in the cache, including expired entries the
+// reaper didn't remove yet, sorted by key
+func (c *Cache) Entries() []Entry {
+	stored := []*cacheEntry{}
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		for _, entry := range s.entries {
+			stored = append(stored, entry)
+		}
+		s.mutex.Unlock()
+	}
+	entries := make([]Entry, 0, len(stored))
+	for _, entry := range stored {
+		if exported, err := entry.export(); err == nil {
+			entries = append(entries, exported)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Peek gets an entry without counting a hit or a miss and without marking
+// it as recently used. Expired entries are returned too.
+func (c *Cache) Peek(key string) (Entry, bool) {
+	key = c.resolve(key)
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	entry, ok := s.entries[key]
+	s.mutex.Unlock()
+	if !ok {
+		return Entry{}, false
+	}
+	exported, err := entry.export()
+	return exported, err == nil
+}
+
+// Delete removes an entry, false if there was no such entry
+func (c *Cache) Delete(key string) bool {
+	key = c.resolve(key)
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	entry, ok := s.entries[key]
+	if ok {
+		s.remove(entry)
+	}
+	s.mutex.Unlock()
+
+	if ok {
+		c.removed([]*cacheEntry{entry}, EvictDeleted)
+	}
+	return ok
+}
+
+// Clear removes every entry and alias, the counters are kept
+func (c *Cache) Clear() {
+	c.aliasMutex.Lock()
+	clear(c.aliases)
+	c.aliasMutex.Unlock()
+
+	removed := []*cacheEntry{}
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		for _, entry := range s.entries {
+			s.remove(entry)
+			removed = append(removed, entry)
+		}
+		s.mutex.Unlock()
+	}
+	c.removed(removed, EvictDeleted)
+}
+
+// export copies an entry, with its value uncompressed. The fields read
+// here never change once the entry is added, so no lock is needed.
+func (e *cacheEntry) export() (Entry, error) {
+	val, err := e.value()
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Key:        e.key,
+		CreatedAt:  e.createdAt,
+		ExpiresAt:  e.expiresAt,
+		Value:      val,
+		Validators: e.validators,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package pokecache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	cache, clock := newFakeCache(t, time.Minute, WithMaxEntries(2))
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("22"))
+	cache.Get("a")
+	cache.Get("missing")
+	cache.Add("c", []byte("333"))                      // evicts b
+	cache.AddWithTTL("d", []byte("4444"), time.Second) // evicts a
+
+	clock.Skip(2 * time.Second)
+	cache.Get("d") // expired
+	cache.reap()
+
+	want := Stats{
+		Hits:        1,
+		Misses:      2,
+		Evictions:   2,
+		Expirations: 1,
+		Entries:     1,
+		Bytes:       3,
+		RawBytes:    3,
+	}
+	if got := cache.Stats(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestEntriesDeleteClear(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute)
+	cache.Add("b", []byte("2"))
+	cache.Add("a", []byte("1"))
+
+	entries := cache.Entries()
+	if len(entries) != 2 || entries[0].Key != "a" || string(entries[1].Value) != "2" {
+		t.Errorf("unexpected entries: %+v", entries)
+		return
+	}
+	if entry, ok := cache.Peek("a"); !ok || entry.ExpiresAt.Sub(entry.CreatedAt) != time.Minute {
+		t.Errorf("unexpected entry: %+v", entry)
+		return
+	}
+	if stats := cache.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("expected Peek and Entries to not count, got %+v", stats)
+		return
+	}
+
+	if !cache.Delete("a") || cache.Delete("a") {
+		t.Errorf("expected to delete a once")
+		return
+	}
+	cache.Clear()
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("expected an empty cache, got %+v", stats)
+	}
+}
@@ -0,0 +1,83 @@
+package pokecache
+
+// Typed keeps the values decoded from the entries of a Cache, so the raw
+// bytes are only decoded once per entry instead of on every hit. Decoded
+// values share the expiry and eviction of their entry, but their size is
+// not counted against WithMaxBytes.
+// Values returned by Get are shared between callers and must not be
+// modified.
+type Typed[T any] struct {
+	cache  *Cache
+	decode func([]byte) (T, error)
+}
+
+// constructor for Typed
+// cache: cache holding the raw bytes
+// decode: turns the raw bytes of an entry into a T
+func NewTyped[T any](cache *Cache, decode func([]byte) (T, error)) *Typed[T] {
+	return &Typed[T]{
+		cache:  cache,
+		decode: decode,
+	}
+}
+
+// Add adds the raw bytes of val to the cache, along with val itself
+func (t *Typed[T]) Add(key string, raw []byte, val T) {
+	t.cache.add(key, raw, t.cache.policyTTL(key), val, Validators{})
+}
+
+// AddWithValidators is Add for a response that can be revalidated
+func (t *Typed[T]) AddWithValidators(key string, raw []byte, val T, validators Validators) {
+	t.cache.add(key, raw, t.cache.policyTTL(key), val, validators)
+}
+
+// Get gets the decoded value of an entry. The raw bytes are decoded on the
+// first Get of an entry added to the Cache directly.
+// false no entry or the entry expired, true entry exists
+func (t *Typed[T]) Get(key string) (T, bool, error) {
+	var zero T
+	entry, ok := t.cache.get(key)
+	if !ok {
+		return zero, false, nil
+	}
+	return t.decoded(entry)
+}
+
+// GetStale gets the decoded value of an expired entry still within the
+// stale retention of the cache, see WithStaleRetention. It does not count
+// as a hit or a miss.
+// false no entry, the entry is fresh or it expired for longer than the
+// retention, true stale entry exists
+func (t *Typed[T]) GetStale(key string) (T, bool, error) {
+	var zero T
+	entry, ok := t.cache.getStale(key)
+	if !ok {
+		return zero, false, nil
+	}
+	return t.decoded(entry)
+}
+
+// decoded returns the decoded value of entry, decoding it on first use
+func (t *Typed[T]) decoded(entry *cacheEntry) (T, bool, error) {
+	var zero T
+	s := t.cache.shardFor(entry.key)
+	s.mutex.Lock()
+	decoded, ok := entry.decoded.(T)
+	s.mutex.Unlock()
+	if ok {
+		return decoded, true, nil
+	}
+
+	raw, err := entry.value()
+	if err != nil {
+		return zero, true, err
+	}
+	decoded, err = t.decode(raw)
+	if err != nil {
+		return zero, true, err
+	}
+	s.mutex.Lock()
+	entry.decoded = decoded
+	s.mutex.Unlock()
+	return decoded, true, nil
+}
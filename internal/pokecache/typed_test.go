@@ -0,0 +1,62 @@
+package pokecache
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTyped(t *testing.T) {
+	cache, clock := newFakeCache(t, time.Minute)
+	decodes := 0
+	typed := NewTyped(cache, func(raw []byte) (int, error) {
+		decodes++
+		return strconv.Atoi(string(raw))
+	})
+
+	// raw entries are decoded once, on the first Get
+	cache.Add("raw", []byte("42"))
+	for i := 0; i < 3; i++ {
+		val, ok, err := typed.Get("raw")
+		if !ok || err != nil || val != 42 {
+			t.Errorf("expected 42, got %v %v %v", val, ok, err)
+			return
+		}
+	}
+	if decodes != 1 {
+		t.Errorf("expected 1 decode, got %d", decodes)
+		return
+	}
+
+	// entries added through Typed are never decoded
+	typed.Add("typed", []byte("7"), 7)
+	if val, ok, _ := typed.Get("typed"); !ok || val != 7 || decodes != 1 {
+		t.Errorf("expected 7 without decoding, got %v after %d decodes", val, decodes)
+		return
+	}
+	if raw, ok := cache.Get("typed"); !ok || string(raw) != "7" {
+		t.Errorf("expected the raw bytes to be cached too")
+		return
+	}
+
+	// decoded values expire with their entry
+	clock.Skip(2 * time.Minute)
+	if _, ok, _ := typed.Get("typed"); ok {
+		t.Errorf("expected to not find typed")
+	}
+}
+
+func TestTypedDecodeError(t *testing.T) {
+	cache, _ := newFakeCache(t, time.Minute)
+	typed := NewTyped(cache, func(raw []byte) (int, error) {
+		return strconv.Atoi(string(raw))
+	})
+	cache.Add("bad", []byte("not a number"))
+
+	_, ok, err := typed.Get("bad")
+	var numErr *strconv.NumError
+	if !ok || !errors.As(err, &numErr) {
+		t.Errorf("expected a decoding error, got %v %v", ok, err)
+	}
+}
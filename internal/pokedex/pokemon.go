@@ -0,0 +1,78 @@
+// Package pokedex holds what the Pokedex keeps about caught Pokemon: a
+// small and stable subset of the PokeAPI resources they come from.
+package pokedex
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokeapi"
+)
+
+// Pokemon is a caught Pokemon
+type Pokemon struct {
+	Name      string    `json:"name"`
+	ID        int       `json:"id"`
+	Height    int       `json:"height"` // in decimetres
+	Weight    int       `json:"weight"` // in hectograms
+	Types     []string  `json:"types"`  // primary type first
+	Stats     []Stat    `json:"stats"`
+	Abilities []Ability `json:"abilities"`
+	Sprites   Sprites   `json:"sprites"`
+}
+
+// Stat is a base stat, such as hp or speed
+type Stat struct {
+	Name string `json:"name"`
+	Base int    `json:"base"`
+}
+
+// Ability is an ability a Pokemon may have
+type Ability struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// Sprites are the URLs of a few images of a Pokemon, empty when missing
+type Sprites struct {
+	Front      string `json:"front,omitempty"`
+	Back       string `json:"back,omitempty"`
+	FrontShiny string `json:"front_shiny,omitempty"`
+	Artwork    string `json:"artwork,omitempty"` // official artwork
+}
+
+// FromAPI maps a Pokemon of the PokeAPI to a Pokemon of the Pokedex
+func FromAPI(p pokeapi.Pokemon) Pokemon {
+	pokemon := Pokemon{
+		Name:   p.Name,
+		ID:     p.ID,
+		Height: p.Height,
+		Weight: p.Weight,
+		Sprites: Sprites{
+			Front:      p.Sprites.FrontDefault,
+			Back:       p.Sprites.BackDefault,
+			FrontShiny: p.Sprites.FrontShiny,
+			Artwork:    p.Sprites.Other.OfficialArtwork.FrontDefault,
+		},
+	}
+
+	// p may be shared with the cache of the client, sort copies
+	types := slices.Clone(p.Types)
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].Slot < types[j].Slot
+	})
+	for _, t := range types {
+		pokemon.Types = append(pokemon.Types, t.Type.Name)
+	}
+	for _, s := range p.Stats {
+		pokemon.Stats = append(pokemon.Stats, Stat{Name: s.Stat.Name, Base: s.BaseStat})
+	}
+	abilities := slices.Clone(p.Abilities)
+	sort.SliceStable(abilities, func(i, j int) bool {
+		return abilities[i].Slot < abilities[j].Slot
+	})
+	for _, a := range abilities {
+		pokemon.Abilities = append(pokemon.Abilities, Ability{Name: a.Ability.Name, Hidden: a.IsHidden})
+	}
+	return pokemon
+}
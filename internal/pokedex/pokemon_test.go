@@ -0,0 +1,58 @@
+package pokedex
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokeapi"
+)
+
+func TestFromAPI(t *testing.T) {
+	body := `{
+		"name": "bulbasaur",
+		"id": 1,
+		"height": 7,
+		"weight": 69,
+		"base_experience": 64,
+		"types": [
+			{"slot": 2, "type": {"name": "poison"}},
+			{"slot": 1, "type": {"name": "grass"}}
+		],
+		"stats": [
+			{"base_stat": 45, "effort": 0, "stat": {"name": "hp"}},
+			{"base_stat": 49, "effort": 0, "stat": {"name": "attack"}}
+		],
+		"abilities": [
+			{"slot": 3, "is_hidden": true, "ability": {"name": "chlorophyll"}},
+			{"slot": 1, "is_hidden": false, "ability": {"name": "overgrow"}}
+		],
+		"moves": [{"move": {"name": "razor-wind"}}],
+		"sprites": {
+			"front_default": "front.png",
+			"back_default": "back.png",
+			"other": {"official-artwork": {"front_default": "artwork.png"}}
+		}
+	}`
+	apiPokemon := pokeapi.Pokemon{}
+	if err := json.Unmarshal([]byte(body), &apiPokemon); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Pokemon{
+		Name:      "bulbasaur",
+		ID:        1,
+		Height:    7,
+		Weight:    69,
+		Types:     []string{"grass", "poison"},
+		Stats:     []Stat{{"hp", 45}, {"attack", 49}},
+		Abilities: []Ability{{"overgrow", false}, {"chlorophyll", true}},
+		Sprites:   Sprites{Front: "front.png", Back: "back.png", Artwork: "artwork.png"},
+	}
+	if actual := FromAPI(apiPokemon); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	if apiPokemon.Types[0].Type.Name != "poison" {
+		t.Errorf("expected the API Pokemon to be left unchanged")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// commandCanceler cancels the running command when Ctrl-C is pressed,
+// instead of exiting the program and losing the Pokedex
+type commandCanceler struct {
+	mutex  sync.Mutex
+	cancel context.CancelFunc // nil while waiting at the prompt
+}
+
+// start returns the context of a new command, and a function to call once
+// the command returns
+func (c *commandCanceler) start() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mutex.Lock()
+	c.cancel = cancel
+	c.mutex.Unlock()
+
+	return ctx, func() {
+		c.mutex.Lock()
+		c.cancel = nil
+		c.mutex.Unlock()
+		cancel()
+	}
+}
+
+// listen cancels the running command for every signal received
+func (c *commandCanceler) listen(signals <-chan os.Signal) {
+	for range signals {
+		c.mutex.Lock()
+		cancel := c.cancel
+		c.mutex.Unlock()
+
+		if cancel != nil {
+			fmt.Println()
+			cancel()
+		} else {
+			fmt.Print("\n(use exit to quit)\npokedex > ")
+		}
+	}
+}
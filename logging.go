@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+// newLogger creates the logger of the CLI and the level it logs from.
+// Warnings, such as stale responses, are always logged, the requests of
+// every command only at the Debug level, with -debug or "debug on".
+// The log goes to stderr, or is appended to the debug file of the settings,
+// which stays open until the CLI exits.
+func newLogger(s settings) (*slog.Logger, *slog.LevelVar, error) {
+	out := io.Writer(os.Stderr)
+	if s.DebugFile != "" {
+		file, err := os.OpenFile(s.DebugFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = file
+	}
+	level := &slog.LevelVar{}
+	level.Set(slog.LevelWarn)
+	if s.Debug {
+		level.Set(slog.LevelDebug)
+	}
+	logger := slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: level}))
+	return logger, level, nil
+}
@@ -2,30 +2,36 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"io"
+	"log/slog"
 	"math/rand"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
+	"github.com/Chrisk1905/pokedexcli/internal/pokeapi"
 	"github.com/Chrisk1905/pokedexcli/internal/pokecache"
+	"github.com/Chrisk1905/pokedexcli/internal/pokedex"
 )
 
 type cliCommand struct {
 	name        string
 	description string
-	callback    func(*Config, []string) error
+	callback    func(context.Context, *Config, []string) error
 }
 
 type Config struct {
-	Next     *string // Pointer to handle absence of a next URL
-	Previous *string // Pointer to handle absence of a previous URL
+	Listings map[string]listing // position of the list command, by resource
+	PageSize int                // results per page of the list command, 0 for the API default
 	Cache    *pokecache.Cache
-	Pokedex  *map[string]Pokemon
+	Client   *pokeapi.Client
+	Pokedex  *map[string]pokedex.Pokemon
+	Logger   *slog.Logger
+	LogLevel *slog.LevelVar // level of Logger, lowered to Debug by the debug command
 }
 
 func getCommands() map[string]cliCommand {
@@ -42,14 +48,19 @@ func getCommands() map[string]cliCommand {
 		},
 		"map": {
 			name:        "map",
-			description: "Displays the next 20 location areas in the Pokemon world",
+			description: "Displays the next location areas in the Pokemon world",
 			callback:    commandMap,
 		},
 		"mapb": {
 			name:        "mapb",
-			description: "Displays the previous 20 location areas",
+			description: "Displays the previous location areas",
 			callback:    commandMapb,
 		},
+		"list": {
+			name:        "list",
+			description: "Displays the next page of a resource: list <resource> [back | all], for example list pokemon",
+			callback:    commandList,
+		},
 		"explore": {
 			name:        "explore",
 			description: "Displays the Pokemon in the given area",
@@ -70,356 +81,25 @@ func getCommands() map[string]cliCommand {
 			description: "print a list of all pokemon in pokedex",
 			callback:    commandPokedex,
 		},
+		"cache": {
+			name:        "cache",
+			description: "Inspect the cache: stats, list, show <key>, evict <key>, clear, export <file>, import <file> [newest|overwrite|skip]",
+			callback:    commandCache,
+		},
+		"offline": {
+			name:        "offline",
+			description: "Answer from the cache only: offline on, offline off",
+			callback:    commandOffline,
+		},
+		"debug": {
+			name:        "debug",
+			description: "Trace the requests, cache hits and timings of every command: debug on, debug off",
+			callback:    commandDebug,
+		},
 	}
 }
 
-type locationAreas struct {
-	Count    int    `json:"count"`
-	Next     string `json:"next"`
-	Previous string `json:"previous"`
-	Results  []struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"results"`
-}
-
-type locationAreasExplore struct {
-	EncounterMethodRates []struct {
-		EncounterMethod struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"encounter_method"`
-		VersionDetails []struct {
-			Rate    int `json:"rate"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"encounter_method_rates"`
-	GameIndex int `json:"game_index"`
-	ID        int `json:"id"`
-	Location  struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"location"`
-	Name  string `json:"name"`
-	Names []struct {
-		Language struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"language"`
-		Name string `json:"name"`
-	} `json:"names"`
-	PokemonEncounters []struct {
-		Pokemon struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"pokemon"`
-		VersionDetails []struct {
-			EncounterDetails []struct {
-				Chance          int   `json:"chance"`
-				ConditionValues []any `json:"condition_values"`
-				MaxLevel        int   `json:"max_level"`
-				Method          struct {
-					Name string `json:"name"`
-					URL  string `json:"url"`
-				} `json:"method"`
-				MinLevel int `json:"min_level"`
-			} `json:"encounter_details"`
-			MaxChance int `json:"max_chance"`
-			Version   struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"pokemon_encounters"`
-}
-
-type Pokemon struct {
-	Abilities []struct {
-		Ability struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"ability"`
-		IsHidden bool `json:"is_hidden"`
-		Slot     int  `json:"slot"`
-	} `json:"abilities"`
-	BaseExperience int `json:"base_experience"`
-	Cries          struct {
-		Latest string `json:"latest"`
-		Legacy string `json:"legacy"`
-	} `json:"cries"`
-	Forms []struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"forms"`
-	GameIndices []struct {
-		GameIndex int `json:"game_index"`
-		Version   struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"version"`
-	} `json:"game_indices"`
-	Height    int `json:"height"`
-	HeldItems []struct {
-		Item struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"item"`
-		VersionDetails []struct {
-			Rarity  int `json:"rarity"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"held_items"`
-	ID                     int    `json:"id"`
-	IsDefault              bool   `json:"is_default"`
-	LocationAreaEncounters string `json:"location_area_encounters"`
-	Moves                  []struct {
-		Move struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"move"`
-		VersionGroupDetails []struct {
-			LevelLearnedAt  int `json:"level_learned_at"`
-			MoveLearnMethod struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"move_learn_method"`
-			VersionGroup struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version_group"`
-		} `json:"version_group_details"`
-	} `json:"moves"`
-	Name          string `json:"name"`
-	Order         int    `json:"order"`
-	PastAbilities []any  `json:"past_abilities"`
-	PastTypes     []struct {
-		Generation struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"generation"`
-		Types []struct {
-			Slot int `json:"slot"`
-			Type struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"type"`
-		} `json:"types"`
-	} `json:"past_types"`
-	Species struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"species"`
-	Sprites struct {
-		BackDefault      string `json:"back_default"`
-		BackFemale       any    `json:"back_female"`
-		BackShiny        string `json:"back_shiny"`
-		BackShinyFemale  any    `json:"back_shiny_female"`
-		FrontDefault     string `json:"front_default"`
-		FrontFemale      any    `json:"front_female"`
-		FrontShiny       string `json:"front_shiny"`
-		FrontShinyFemale any    `json:"front_shiny_female"`
-		Other            struct {
-			DreamWorld struct {
-				FrontDefault string `json:"front_default"`
-				FrontFemale  any    `json:"front_female"`
-			} `json:"dream_world"`
-			Home struct {
-				FrontDefault     string `json:"front_default"`
-				FrontFemale      any    `json:"front_female"`
-				FrontShiny       string `json:"front_shiny"`
-				FrontShinyFemale any    `json:"front_shiny_female"`
-			} `json:"home"`
-			OfficialArtwork struct {
-				FrontDefault string `json:"front_default"`
-				FrontShiny   string `json:"front_shiny"`
-			} `json:"official-artwork"`
-			Showdown struct {
-				BackDefault      string `json:"back_default"`
-				BackFemale       any    `json:"back_female"`
-				BackShiny        string `json:"back_shiny"`
-				BackShinyFemale  any    `json:"back_shiny_female"`
-				FrontDefault     string `json:"front_default"`
-				FrontFemale      any    `json:"front_female"`
-				FrontShiny       string `json:"front_shiny"`
-				FrontShinyFemale any    `json:"front_shiny_female"`
-			} `json:"showdown"`
-		} `json:"other"`
-		Versions struct {
-			GenerationI struct {
-				RedBlue struct {
-					BackDefault      string `json:"back_default"`
-					BackGray         string `json:"back_gray"`
-					BackTransparent  string `json:"back_transparent"`
-					FrontDefault     string `json:"front_default"`
-					FrontGray        string `json:"front_gray"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"red-blue"`
-				Yellow struct {
-					BackDefault      string `json:"back_default"`
-					BackGray         string `json:"back_gray"`
-					BackTransparent  string `json:"back_transparent"`
-					FrontDefault     string `json:"front_default"`
-					FrontGray        string `json:"front_gray"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"yellow"`
-			} `json:"generation-i"`
-			GenerationIi struct {
-				Crystal struct {
-					BackDefault           string `json:"back_default"`
-					BackShiny             string `json:"back_shiny"`
-					BackShinyTransparent  string `json:"back_shiny_transparent"`
-					BackTransparent       string `json:"back_transparent"`
-					FrontDefault          string `json:"front_default"`
-					FrontShiny            string `json:"front_shiny"`
-					FrontShinyTransparent string `json:"front_shiny_transparent"`
-					FrontTransparent      string `json:"front_transparent"`
-				} `json:"crystal"`
-				Gold struct {
-					BackDefault      string `json:"back_default"`
-					BackShiny        string `json:"back_shiny"`
-					FrontDefault     string `json:"front_default"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"gold"`
-				Silver struct {
-					BackDefault      string `json:"back_default"`
-					BackShiny        string `json:"back_shiny"`
-					FrontDefault     string `json:"front_default"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"silver"`
-			} `json:"generation-ii"`
-			GenerationIii struct {
-				Emerald struct {
-					FrontDefault string `json:"front_default"`
-					FrontShiny   string `json:"front_shiny"`
-				} `json:"emerald"`
-				FireredLeafgreen struct {
-					BackDefault  string `json:"back_default"`
-					BackShiny    string `json:"back_shiny"`
-					FrontDefault string `json:"front_default"`
-					FrontShiny   string `json:"front_shiny"`
-				} `json:"firered-leafgreen"`
-				RubySapphire struct {
-					BackDefault  string `json:"back_default"`
-					BackShiny    string `json:"back_shiny"`
-					FrontDefault string `json:"front_default"`
-					FrontShiny   string `json:"front_shiny"`
-				} `json:"ruby-sapphire"`
-			} `json:"generation-iii"`
-			GenerationIv struct {
-				DiamondPearl struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"diamond-pearl"`
-				HeartgoldSoulsilver struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"heartgold-soulsilver"`
-				Platinum struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"platinum"`
-			} `json:"generation-iv"`
-			GenerationV struct {
-				BlackWhite struct {
-					Animated struct {
-						BackDefault      string `json:"back_default"`
-						BackFemale       any    `json:"back_female"`
-						BackShiny        string `json:"back_shiny"`
-						BackShinyFemale  any    `json:"back_shiny_female"`
-						FrontDefault     string `json:"front_default"`
-						FrontFemale      any    `json:"front_female"`
-						FrontShiny       string `json:"front_shiny"`
-						FrontShinyFemale any    `json:"front_shiny_female"`
-					} `json:"animated"`
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"black-white"`
-			} `json:"generation-v"`
-			GenerationVi struct {
-				OmegarubyAlphasapphire struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"omegaruby-alphasapphire"`
-				XY struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"x-y"`
-			} `json:"generation-vi"`
-			GenerationVii struct {
-				Icons struct {
-					FrontDefault string `json:"front_default"`
-					FrontFemale  any    `json:"front_female"`
-				} `json:"icons"`
-				UltraSunUltraMoon struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"ultra-sun-ultra-moon"`
-			} `json:"generation-vii"`
-			GenerationViii struct {
-				Icons struct {
-					FrontDefault string `json:"front_default"`
-					FrontFemale  any    `json:"front_female"`
-				} `json:"icons"`
-			} `json:"generation-viii"`
-		} `json:"versions"`
-	} `json:"sprites"`
-	Stats []struct {
-		BaseStat int `json:"base_stat"`
-		Effort   int `json:"effort"`
-		Stat     struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"stat"`
-	} `json:"stats"`
-	Types []struct {
-		Slot int `json:"slot"`
-		Type struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"type"`
-	} `json:"types"`
-	Weight int `json:"weight"`
-}
-
-func commandHelp(config *Config, args []string) error {
+func commandHelp(ctx context.Context, config *Config, args []string) error {
 	commands := getCommands()
 	for _, c := range commands {
 		fmt.Printf("%s: %s \n", c.name, c.description)
@@ -427,208 +107,58 @@ func commandHelp(config *Config, args []string) error {
 	return nil
 }
 
-func commandExit(config *Config, args []string) error {
+func commandExit(ctx context.Context, config *Config, args []string) error {
+	// let the background refreshes finish, then flush the cache before
+	// leaving
+	config.Client.Wait()
+	if err := config.Cache.Close(); err != nil {
+		fmt.Println("Error closing cache:", err)
+	}
 	os.Exit(0) // Exits the program
 	return nil // This line will never be reached
 }
 
-func commandMap(config *Config, args []string) error {
-	//get URL
-	var urlToCall string
-	if config.Next != nil {
-		urlToCall = *config.Next // Use the next URL if available
-	} else {
-		urlToCall = "https://pokeapi.co/api/v2/location-area/" // Default URL
-	}
-	//check cache
-	val, ok := config.Cache.Get(urlToCall)
-	locationArea := locationAreas{}
-	if ok {
-		fmt.Println("found in cache")
-		err := json.Unmarshal(val, &locationArea)
-		if err != nil {
-			return err
-		}
-		for _, area := range locationArea.Results {
-			fmt.Println(area.Name)
-		}
-		config.Next = &locationArea.Next
-		config.Previous = &locationArea.Previous
-		return nil
-	}
-	//http get call
-	res, err := http.Get(urlToCall)
-	if err != nil {
-		return err
-	}
-	body, err := io.ReadAll(res.Body)
-	res.Body.Close()
-	if res.StatusCode > 299 {
-		return fmt.Errorf("response failed with status code: %d and\nbody: %s", res.StatusCode, body)
-	}
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(body, &locationArea)
-	if err != nil {
-		return err
-	}
-	for _, area := range locationArea.Results {
-		fmt.Println(area.Name)
-	}
-	config.Next = &locationArea.Next
-	config.Previous = &locationArea.Previous
-	//add result to cache
-	config.Cache.Add(urlToCall, body)
-	return nil
+func commandMap(ctx context.Context, config *Config, args []string) error {
+	return listPage(ctx, config, "location-area", false)
 }
 
-func commandMapb(config *Config, args []string) error {
-	if config.Previous == nil || *config.Previous == "" {
-		return errors.New("no previous page")
-	}
-	//check cache first
-	val, ok := config.Cache.Get(*config.Previous)
-	locationArea := locationAreas{}
-	if ok {
-		fmt.Println("found in cache")
-		err := json.Unmarshal(val, &locationArea)
-		if err != nil {
-			return err
-		}
-		for _, area := range locationArea.Results {
-			fmt.Println(area.Name)
-		}
-		config.Next = &locationArea.Next
-		config.Previous = &locationArea.Previous
-		return nil
-	}
-	res, err := http.Get(*config.Previous)
-	if err != nil {
-		return err
-	}
-	body, err := io.ReadAll(res.Body)
-	res.Body.Close()
-	if res.StatusCode > 299 {
-		return fmt.Errorf("response failed with status code: %d and\nbody: %s", res.StatusCode, body)
-	}
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(body, &locationArea)
-	if err != nil {
-		return err
-	}
-	for _, area := range locationArea.Results {
-		fmt.Println(area.Name)
-	}
-	config.Next = &locationArea.Next
-	config.Previous = &locationArea.Previous
-	//add to cache
-	config.Cache.Add(*config.Previous, body)
-	return nil
+func commandMapb(ctx context.Context, config *Config, args []string) error {
+	return listPage(ctx, config, "location-area", true)
 }
 
-func commandExplore(config *Config, args []string) error {
+func commandExplore(ctx context.Context, config *Config, args []string) error {
 	// edge case
 	if len(args) == 0 {
 		return fmt.Errorf("no area specified")
 	}
 	var areaName string = args[0]
-	urlToCall := fmt.Sprintf("https://pokeapi.co/api/v2/location-area/%s", areaName)
-	// check cache
-	val, ok := config.Cache.Get(urlToCall)
-	locationAreasExplore := locationAreasExplore{}
-	if ok {
-		fmt.Println("Found in Cache")
-		err := json.Unmarshal(val, &locationAreasExplore)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("exploring %s", locationAreasExplore.Name)
-		for _, pokemonEncounter := range locationAreasExplore.PokemonEncounters {
-			fmt.Println(pokemonEncounter.Pokemon.Name)
-		}
-		return nil
-	}
-	// make api call
-	res, err := http.Get(urlToCall)
-	if err != nil {
-		return err
-	}
-	body, err := io.ReadAll(res.Body)
-	res.Body.Close()
-	if res.StatusCode > 299 {
-		return fmt.Errorf("response failed with status code: %d and\nbody: %s", res.StatusCode, body)
-	}
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(body, &locationAreasExplore)
+	locationAreasExplore, err := config.Client.GetLocationArea(ctx, areaName)
 	if err != nil {
 		return err
 	}
+	fmt.Printf("exploring %s \n", locationAreasExplore.Name)
 	for _, pokemonEncounter := range locationAreasExplore.PokemonEncounters {
 		fmt.Println(pokemonEncounter.Pokemon.Name)
 	}
-	// add to cache
-	config.Cache.Add(urlToCall, body)
 	return nil
 }
 
-func commandCatch(config *Config, args []string) error {
+func commandCatch(ctx context.Context, config *Config, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no pokemon given")
 	}
 	var pokemonArg string = args[0]
-	urlToCall := fmt.Sprintf("https://pokeapi.co/api/v2/pokemon/%s", strings.ToLower(pokemonArg))
-	//check cache
-	val, ok := config.Cache.Get(urlToCall)
-	pokemon := Pokemon{}
-	if ok {
-		fmt.Println("found in cache")
-		err := json.Unmarshal(val, &pokemon)
-		if err != nil {
-			return err
-		}
-		//try to catch
-		fmt.Printf("Throwing a pokeball at %s... \n", pokemon.Name)
-		randomChance := rand.Intn(1000)
-		if randomChance > pokemon.BaseExperience {
-			pokedex := *config.Pokedex
-			pokedex[pokemon.Name] = pokemon
-			fmt.Printf("%s was caught! \n", pokemon.Name)
-			return nil
-		} else {
-			fmt.Printf("%s escaped! \n", pokemon.Name)
-		}
-		return nil
-	}
-	//make api call
-	res, err := http.Get(urlToCall)
+	pokemon, err := config.Client.GetPokemon(ctx, pokemonArg)
 	if err != nil {
 		return err
 	}
-	body, err := io.ReadAll(res.Body)
-	res.Body.Close()
-	if res.StatusCode > 299 {
-		return fmt.Errorf("response failed with status code: %d and\nbody: %s", res.StatusCode, body)
-	}
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(body, &pokemon)
-	if err != nil {
-		return err
-	}
-	//add to cache
-	config.Cache.Add(urlToCall, body)
 	//try to catch
 	fmt.Printf("Throwing a pokeball at %s... \n", pokemon.Name)
 	randomChance := rand.Intn(800)
 	if randomChance > pokemon.BaseExperience {
-		pokedex := *config.Pokedex
-		pokedex[pokemon.Name] = pokemon
+		caught := *config.Pokedex
+		// only keep what the Pokedex shows, not the whole API response
+		caught[pokemon.Name] = pokedex.FromAPI(pokemon)
 		fmt.Printf("%s was caught! \n", pokemon.Name)
 		return nil
 	} else {
@@ -637,7 +167,7 @@ func commandCatch(config *Config, args []string) error {
 	return nil
 }
 
-func commandInspect(config *Config, args []string) error {
+func commandInspect(ctx context.Context, config *Config, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no pokemon given")
 	}
@@ -652,11 +182,19 @@ func commandInspect(config *Config, args []string) error {
 		fmt.Printf("Weight: %v \n", pokemon.Weight)
 		fmt.Print("Stats: \n")
 		for _, stat := range pokemon.Stats {
-			fmt.Printf(" . -%s: %v \n", stat.Stat.Name, stat.BaseStat)
+			fmt.Printf(" . -%s: %v \n", stat.Name, stat.Base)
 		}
 		fmt.Print("Types: \n")
 		for _, t := range pokemon.Types {
-			fmt.Printf(" . - %s \n", t.Type.Name)
+			fmt.Printf(" . - %s \n", t)
+		}
+		fmt.Print("Abilities: \n")
+		for _, ability := range pokemon.Abilities {
+			if ability.Hidden {
+				fmt.Printf(" . - %s (hidden) \n", ability.Name)
+			} else {
+				fmt.Printf(" . - %s \n", ability.Name)
+			}
 		}
 		return nil
 	}
@@ -664,7 +202,7 @@ func commandInspect(config *Config, args []string) error {
 	return nil
 }
 
-func commandPokedex(config *Config, args []string) error {
+func commandPokedex(ctx context.Context, config *Config, args []string) error {
 	for name := range *config.Pokedex {
 		fmt.Printf(" . -%s\n", name)
 	}
@@ -672,26 +210,90 @@ func commandPokedex(config *Config, args []string) error {
 	return nil
 }
 
+// newCache creates the cache selected in the settings
+func newCache(s settings, cleanInterval time.Duration) (*pokecache.Cache, error) {
+	opts := []pokecache.Option{
+		pokecache.WithMaxEntries(s.CacheMaxEntries),
+		pokecache.WithMaxBytes(s.CacheMaxBytes),
+		// single resources almost never change, list pages use cleanInterval
+		pokecache.WithTTLPolicy(pokeapi.TTLPolicy(24*time.Hour, 0)),
+		// expired responses are kept a while longer, so they can be
+		// revalidated with a conditional request instead of downloaded
+		// again, or served when the API can't be reached
+		pokecache.WithStaleRetention(time.Duration(s.CacheStale)),
+	}
+	if s.CacheCompress {
+		opts = append(opts, pokecache.WithCompression())
+	}
+	if s.Cache == "disk" {
+		return pokecache.NewDiskCache(s.CacheDir, cleanInterval, opts...)
+	}
+	return pokecache.NewCache(cleanInterval, opts...), nil
+}
+
 func main() {
+	settings, err := loadSettings(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+	logger, logLevel, err := newLogger(settings)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	// packages without a logger of their own, such as pokecache, log here too
+	slog.SetDefault(logger)
 	scanner := bufio.NewScanner(os.Stdin)
 	commands := getCommands()
-	fmt.Println("creating cleanInterval..")
+	logger.Debug("creating cleanInterval..")
 	cleanInterval, err := time.ParseDuration("1m")
 	if err != nil {
-		fmt.Printf("error creating time.ParseDuration %v", err)
+		logger.Error("creating cleanInterval", "err", err)
 	}
-	fmt.Println("initializing cache..")
-	cache := pokecache.NewCache(cleanInterval)
-	fmt.Println("initializing Pokedex..")
-	pokedex := make(map[string]Pokemon)
-	fmt.Println("intializing config..")
+	logger.Debug("initializing cache..")
+	cache, err := newCache(settings, cleanInterval)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	logger.Debug("initializing Pokedex..")
+	caught := make(map[string]pokedex.Pokemon)
+	logger.Debug("initializing client..")
+	clientOpts := []pokeapi.Option{
+		pokeapi.WithTimeout(time.Duration(settings.Timeout)),
+		pokeapi.WithStaleWhileRevalidate(),
+		pokeapi.WithRateLimit(settings.RateLimit, settings.RateBurst),
+		pokeapi.WithLogger(logger),
+	}
+	if settings.DataDir != "" {
+		backend, err := pokeapi.NewDumpBackend(settings.DataDir)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		clientOpts = append(clientOpts, pokeapi.WithBackend(backend))
+	}
+	client := pokeapi.NewClient(cache, settings.BaseURL, clientOpts...)
+	client.SetOffline(settings.Offline)
+	logger.Debug("intializing config..")
 	config := &Config{
-		Next:     nil,
-		Previous: nil,
+		Listings: make(map[string]listing),
+		PageSize: settings.PageSize,
 		Cache:    cache,
-		Pokedex:  &pokedex,
-	}
-	fmt.Println("starting REPL..")
+		Client:   client,
+		Pokedex:  &caught,
+		Logger:   logger,
+		LogLevel: logLevel,
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	canceler := &commandCanceler{}
+	go canceler.listen(interrupts)
+	logger.Debug("starting REPL..")
 	for {
 		fmt.Print("pokedex > ")
 		if scanner.Scan() {
@@ -699,12 +301,23 @@ func main() {
 			split_text := strings.Split(text, " ")
 			args := split_text[1:]
 			if command, exists := commands[split_text[0]]; exists {
-				if err := command.callback(config, args); err != nil {
+				ctx, done := canceler.start()
+				start := time.Now()
+				logger.Debug("command started", "command", text)
+				err := command.callback(ctx, config, args)
+				done()
+				logger.Debug("command done", "command", text, "latency", time.Since(start), "err", err)
+				if errors.Is(err, context.Canceled) {
+					fmt.Println("Command cancelled")
+				} else if err != nil {
 					fmt.Println("Error:", err)
 				}
 			} else {
 				fmt.Println("Unknown command:", text)
 			}
+		} else {
+			// stdin was closed (Ctrl-D)
+			commandExit(context.Background(), config, nil)
 		}
 	}
 }
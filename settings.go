@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Chrisk1905/pokedexcli/internal/pokeapi"
+)
+
+// settings configure the CLI at startup.
+// Values are read from the config file, then the environment, then the
+// command line flags, each one overriding the one before it.
+type settings struct {
+	BaseURL  string   `json:"base_url"`
+	DataDir  string   `json:"data_dir"` // PokeAPI dump read instead of the API
+	Timeout  duration `json:"timeout"`
+	Cache    string   `json:"cache"`     // "memory" or "disk"
+	CacheDir string   `json:"cache_dir"` // directory of the disk cache
+
+	CacheMaxEntries int `json:"cache_max_entries"` // 0 means no limit
+	CacheMaxBytes   int `json:"cache_max_bytes"`   // 0 means no limit
+
+	CacheCompress bool     `json:"cache_compress"` // gzip cached responses
+	CacheStale    duration `json:"cache_stale"`    // how long expired responses are kept
+
+	Offline bool `json:"offline"` // answer from the cache only
+
+	RateLimit float64 `json:"rate_limit"` // API requests per second, 0 means no limit
+	RateBurst int     `json:"rate_burst"` // API requests allowed at once above the rate
+
+	Debug     bool   `json:"debug"`      // trace requests, cache decisions and timings
+	DebugFile string `json:"debug_file"` // file the log is appended to instead of stderr
+
+	PageSize int `json:"page_size"` // results per page of the list commands
+}
+
+// duration is a time.Duration written as a string such as "10s" in the
+// config file
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// environment variables read by loadSettings
+const (
+	envConfigFile = "POKEDEX_CONFIG"
+	envBaseURL    = "POKEDEX_BASE_URL"
+	envDataDir    = "POKEDEX_DATA_DIR"
+	envTimeout    = "POKEDEX_TIMEOUT"
+	envCache      = "POKEDEX_CACHE"
+	envCacheDir   = "POKEDEX_CACHE_DIR"
+
+	envCacheMaxEntries = "POKEDEX_CACHE_MAX_ENTRIES"
+	envCacheMaxBytes   = "POKEDEX_CACHE_MAX_BYTES"
+	envCacheCompress   = "POKEDEX_CACHE_COMPRESS"
+	envCacheStale      = "POKEDEX_CACHE_STALE"
+	envOffline         = "POKEDEX_OFFLINE"
+	envRateLimit       = "POKEDEX_RATE_LIMIT"
+	envRateBurst       = "POKEDEX_RATE_BURST"
+	envDebug           = "POKEDEX_DEBUG"
+	envDebugFile       = "POKEDEX_DEBUG_FILE"
+	envPageSize        = "POKEDEX_PAGE_SIZE"
+)
+
+const (
+	// defaultCacheStale is how long expired responses are kept by default
+	defaultCacheStale = 24 * time.Hour
+
+	// a burst of commands is answered right away, bulk requests are spread
+	// out to stay within the fair use policy of the PokeAPI
+	defaultRateLimit = 10
+	defaultRateBurst = 20
+
+	// defaultPageSize is the page size of the PokeAPI
+	defaultPageSize = 20
+)
+
+func defaultSettings() settings {
+	return settings{
+		BaseURL:  pokeapi.DefaultBaseURL,
+		Timeout:  duration(pokeapi.DefaultTimeout),
+		Cache:    "memory",
+		CacheDir: defaultCacheDir(),
+
+		CacheStale: duration(defaultCacheStale),
+		RateLimit:  defaultRateLimit,
+		RateBurst:  defaultRateBurst,
+		PageSize:   defaultPageSize,
+	}
+}
+
+// defaultCacheDir returns the directory of the disk cache used when none is
+// given, for example ~/.cache/pokedexcli on Linux
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pokedexcli")
+}
+
+// defaultConfigFile returns the path of the config file used when none is
+// given, for example ~/.config/pokedexcli/config.json on Linux
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pokedexcli", "config.json")
+}
+
+// loadSettings builds the settings from the config file, the environment
+// and the command line arguments (without the program name)
+func loadSettings(args []string) (settings, error) {
+	s := defaultSettings()
+
+	flags := flag.NewFlagSet("pokedexcli", flag.ContinueOnError)
+	configFile := flags.String("config", "", "path to a JSON config file (env "+envConfigFile+")")
+	baseURL := flags.String("base-url", "", "root URL of the PokeAPI (env "+envBaseURL+")")
+	dataDir := flags.String("data-dir", "", "directory of a PokeAPI dump (api/v2/...) to read instead of the API (env "+envDataDir+")")
+	cache := flags.String("cache", "memory", "cache kind, memory or disk (env "+envCache+")")
+	cacheDir := flags.String("cache-dir", defaultCacheDir(), "directory of the disk cache (env "+envCacheDir+")")
+	cacheMaxEntries := flags.Int("cache-max-entries", 0, "maximum number of cached responses, 0 for no limit (env "+envCacheMaxEntries+")")
+	cacheMaxBytes := flags.Int("cache-max-bytes", 0, "maximum total size of cached responses, 0 for no limit (env "+envCacheMaxBytes+")")
+	cacheCompress := flags.Bool("cache-compress", false, "gzip cached responses (env "+envCacheCompress+")")
+	cacheStale := flags.Duration("cache-stale", defaultCacheStale, "how long expired responses are kept, to be revalidated or served when offline (env "+envCacheStale+")")
+	offline := flags.Bool("offline", false, "answer from the cache only, without network calls (env "+envOffline+")")
+	rateLimit := flags.Float64("rate-limit", defaultRateLimit, "maximum API requests per second, 0 for no limit (env "+envRateLimit+")")
+	rateBurst := flags.Int("rate-burst", defaultRateBurst, "API requests allowed at once above the rate limit (env "+envRateBurst+")")
+	pageSize := flags.Int("page-size", defaultPageSize, "results per page of map, mapb and list (env "+envPageSize+")")
+	debug := flags.Bool("debug", false, "log the requests of every command, cache hits and misses and timings (env "+envDebug+")")
+	debugFile := flags.String("debug-file", "", "append the log to this file instead of stderr (env "+envDebugFile+")")
+	timeout := flags.Duration("timeout", pokeapi.DefaultTimeout, "timeout of a single API request, 0 for none (env "+envTimeout+")")
+	if err := flags.Parse(args); err != nil {
+		return s, err
+	}
+
+	// config file
+	path, required := *configFile, true
+	if path == "" {
+		path = os.Getenv(envConfigFile)
+	}
+	if path == "" {
+		path, required = defaultConfigFile(), false
+	}
+	if path != "" {
+		if err := s.readFile(path); err != nil {
+			if required || !errors.Is(err, fs.ErrNotExist) {
+				return s, err
+			}
+		}
+	}
+
+	// environment
+	if val, ok := os.LookupEnv(envBaseURL); ok {
+		s.BaseURL = val
+	}
+	if val, ok := os.LookupEnv(envDataDir); ok {
+		s.DataDir = val
+	}
+	for name, dst := range map[string]*duration{
+		envTimeout:    &s.Timeout,
+		envCacheStale: &s.CacheStale,
+	} {
+		if val, ok := os.LookupEnv(name); ok {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return s, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = duration(d)
+		}
+	}
+	if val, ok := os.LookupEnv(envCache); ok {
+		s.Cache = val
+	}
+	if val, ok := os.LookupEnv(envCacheDir); ok {
+		s.CacheDir = val
+	}
+	if val, ok := os.LookupEnv(envDebugFile); ok {
+		s.DebugFile = val
+	}
+	for name, dst := range map[string]*bool{
+		envCacheCompress: &s.CacheCompress,
+		envOffline:       &s.Offline,
+		envDebug:         &s.Debug,
+	} {
+		if val, ok := os.LookupEnv(name); ok {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return s, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = b
+		}
+	}
+	if val, ok := os.LookupEnv(envRateLimit); ok {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s: %w", envRateLimit, err)
+		}
+		s.RateLimit = f
+	}
+	for name, dst := range map[string]*int{
+		envCacheMaxEntries: &s.CacheMaxEntries,
+		envCacheMaxBytes:   &s.CacheMaxBytes,
+		envRateBurst:       &s.RateBurst,
+		envPageSize:        &s.PageSize,
+	} {
+		if val, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return s, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+
+	// flags
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "base-url":
+			s.BaseURL = *baseURL
+		case "data-dir":
+			s.DataDir = *dataDir
+		case "timeout":
+			s.Timeout = duration(*timeout)
+		case "cache":
+			s.Cache = *cache
+		case "cache-dir":
+			s.CacheDir = *cacheDir
+		case "cache-max-entries":
+			s.CacheMaxEntries = *cacheMaxEntries
+		case "cache-max-bytes":
+			s.CacheMaxBytes = *cacheMaxBytes
+		case "cache-compress":
+			s.CacheCompress = *cacheCompress
+		case "cache-stale":
+			s.CacheStale = duration(*cacheStale)
+		case "offline":
+			s.Offline = *offline
+		case "rate-limit":
+			s.RateLimit = *rateLimit
+		case "rate-burst":
+			s.RateBurst = *rateBurst
+		case "debug":
+			s.Debug = *debug
+		case "debug-file":
+			s.DebugFile = *debugFile
+		case "page-size":
+			s.PageSize = *pageSize
+		}
+	})
+
+	return s, s.validate()
+}
+
+// validate reports settings that can't be used
+func (s settings) validate() error {
+	if s.PageSize < 1 {
+		return fmt.Errorf("invalid page size %d", s.PageSize)
+	}
+	switch s.Cache {
+	case "memory":
+	case "disk":
+		if s.CacheDir == "" {
+			return errors.New("the disk cache needs a cache directory")
+		}
+	default:
+		return fmt.Errorf("unknown cache %q, expected memory or disk", s.Cache)
+	}
+	return nil
+}
+
+// readFile overrides the settings with the values set in a JSON file
+func (s *settings) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("reading config file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file in a temporary directory and returns
+// its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSettingsPrecedence(t *testing.T) {
+	// no config file in the default location
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := writeConfig(t, `{"base_url": "http://file/api/v2", "timeout": "5s", "page_size": 5}`)
+	t.Setenv(envBaseURL, "http://env/api/v2")
+	t.Setenv(envTimeout, "7s")
+
+	cases := []struct {
+		name     string
+		args     []string
+		expected settings
+	}{
+		{
+			name: "env overrides the config file",
+			args: []string{"-config", path},
+			expected: settings{
+				BaseURL:  "http://env/api/v2",
+				Timeout:  duration(7 * time.Second),
+				PageSize: 5,
+			},
+		},
+		{
+			name: "flags override the env",
+			args: []string{"-config", path, "-base-url", "http://flag/api/v2", "-page-size", "10"},
+			expected: settings{
+				BaseURL:  "http://flag/api/v2",
+				Timeout:  duration(7 * time.Second),
+				PageSize: 10,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := loadSettings(c.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.BaseURL != c.expected.BaseURL || s.Timeout != c.expected.Timeout || s.PageSize != c.expected.PageSize {
+				t.Errorf("expected %+v, got %+v", c.expected, s)
+			}
+			// settings set nowhere keep their default
+			if s.RateBurst != defaultRateBurst {
+				t.Errorf("expected the default rate burst, got %d", s.RateBurst)
+			}
+		})
+	}
+
+	// the config file can be named by the env too, and is read without
+	// the env overriding it
+	t.Setenv(envConfigFile, path)
+	os.Unsetenv(envBaseURL) // restored by the t.Setenv above
+	s, err := loadSettings(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.BaseURL != "http://file/api/v2" {
+		t.Errorf("expected the config file named by %s to be read, got %+v", envConfigFile, s)
+	}
+}
+
+func TestLoadSettingsConfigFile(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	missing := filepath.Join(t.TempDir(), "missing.json")
+
+	// a missing default config file is not an error
+	if _, err := loadSettings(nil); err != nil {
+		t.Errorf("unexpected error without a config file: %v", err)
+	}
+
+	// a config file asked for must exist
+	if _, err := loadSettings([]string{"-config", missing}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a missing -config file to fail, got %v", err)
+	}
+	t.Setenv(envConfigFile, missing)
+	if _, err := loadSettings(nil); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a missing %s file to fail, got %v", envConfigFile, err)
+	}
+	os.Unsetenv(envConfigFile)
+
+	// the default config file is read when it exists
+	path := filepath.Join(configHome, "pokedexcli", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"page_size": 3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadSettings(nil)
+	if err != nil || s.PageSize != 3 {
+		t.Errorf("expected the default config file to be read, got %+v, %v", s, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSettings(nil); err == nil {
+		t.Errorf("expected an invalid default config file to fail")
+	}
+}